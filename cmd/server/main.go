@@ -0,0 +1,413 @@
+package main
+
+import (
+	"context"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"github.com/rishabh998186/kdash-monitor/internal/handlers"
+	"github.com/rishabh998186/kdash-monitor/internal/models"
+	"github.com/rishabh998186/kdash-monitor/internal/notifications"
+	"github.com/rishabh998186/kdash-monitor/internal/services"
+	"github.com/rishabh998186/kdash-monitor/internal/storage"
+	"github.com/rishabh998186/kdash-monitor/internal/tracing"
+)
+
+// buildVersion is overridden at build time via
+// -ldflags "-X main.buildVersion=...".
+var buildVersion = "dev"
+
+// apiVersion names the current API version served under /api/v1.
+const apiVersion = "v1"
+
+func main() {
+	port := getenv("PORT", "8080")
+	// BIND_ADDR restricts the listen interface (e.g. 127.0.0.1); empty
+	// keeps the historical all-interfaces behavior.
+	bindAddr := os.Getenv("BIND_ADDR")
+	tlsCert := os.Getenv("TLS_CERT")
+	tlsKey := os.Getenv("TLS_KEY")
+	if (tlsCert == "") != (tlsKey == "") {
+		log.Fatal("TLS_CERT and TLS_KEY must be set together")
+	}
+	dbPath := getenv("DB_PATH", "data/metrics.db")
+	configPath := getenv("CLUSTERS_CONFIG", "clusters.yaml")
+	interval := getenvDuration("COLLECTION_INTERVAL", time.Minute)
+	retention := getenvDuration("RETENTION", 7*24*time.Hour)
+	// Per-call timeouts; both sit inside the overall 30s request
+	// context the API handlers impose, so raising them past 30s only
+	// benefits the background collector.
+	promTimeout := getenvDuration("PROM_TIMEOUT", services.DefaultPrometheusTimeout)
+	k8sTimeout := getenvDuration("K8S_TIMEOUT", services.DefaultConnectTimeout)
+	readOnly := os.Getenv("READ_ONLY") == "true"
+
+	if dbPath != storage.InMemoryDBPath {
+		if err := os.MkdirAll(filepath.Dir(dbPath), 0o755); err != nil {
+			log.Fatalf("creating data directory: %v", err)
+		}
+	}
+	store, err := storage.NewMetricsStore(dbPath)
+	if err != nil {
+		log.Fatalf("initializing metrics store: %v", err)
+	}
+
+	k8sService, err := services.NewKubernetesService(configPath, k8sTimeout)
+	if err != nil {
+		log.Printf("warning: kubernetes service unavailable, continuing in degraded mode: %v", err)
+		k8sService = nil
+	}
+	if k8sService != nil {
+		k8sService.StartClientRetry()
+		k8sService.SetCountSucceededPods(os.Getenv("POD_COUNT_INCLUDE_SUCCEEDED") == "true")
+	}
+	promService := services.NewPrometheusService(promTimeout)
+	if k8sService != nil {
+		for _, cfg := range k8sService.GetConfigs() {
+			promService.SetQueryOverrides(cfg.PrometheusURL, cfg.Queries)
+		}
+	}
+	notifiers := notifications.FromEnv()
+	thresholds := services.DefaultThresholds()
+
+	var collector *services.MetricsCollector
+	if k8sService != nil {
+		collector = services.NewMetricsCollector(k8sService, promService, store, notifiers, thresholds, interval)
+		if policy := parseEscalationPolicy(os.Getenv("ALERT_ESCALATION")); len(policy) > 0 {
+			collector.SetEscalationPolicy(policy, os.Getenv("ALERT_ESCALATION_RENOTIFY") == "true")
+		}
+		collector.SetDisabledKinds(k8sService.DisabledAlertKinds())
+		collector.SetStagger(getenv("COLLECTION_STAGGER", "true") == "true")
+		collector.SetWarmup(getenvDuration("ALERT_WARMUP", services.DefaultAlertWarmup))
+		collector.SetDeadband(getenvFloat("SNAPSHOT_DEADBAND", 0), getenvDuration("SNAPSHOT_HEARTBEAT", services.DefaultSnapshotHeartbeat))
+		if window := getenvDuration("TREND_WINDOW", 0); window > 0 {
+			collector.SetTrendPolicy(window, getenvFloat("TREND_RATE", 20))
+		}
+		collector.RestoreAlertState()
+		collector.Start()
+		defer collector.Stop()
+		if rules := parseAlertRules(os.Getenv("ALERT_RULES")); len(rules) > 0 {
+			evaluator := services.NewAlertEvaluator(collector, store, rules, getenvDuration("ALERT_EVAL_INTERVAL", services.DefaultEvalInterval))
+			evaluator.Start()
+			defer evaluator.Stop()
+		}
+	}
+
+	go runRetentionCleanup(store, retention)
+
+	shutdownTracing, err := tracing.Setup(context.Background())
+	if err != nil {
+		log.Printf("warning: tracing disabled: %v", err)
+	} else {
+		defer shutdownTracing(context.Background())
+	}
+
+	router := gin.Default()
+	router.Use(handlers.TracingMiddleware())
+
+	// LoadHTMLGlob panics when the glob matches nothing, which is just a
+	// binary run from an unexpected working directory. Degrade to
+	// API-only mode instead of crashing a monitoring tool over its own
+	// dashboard.
+	templatesGlob := getenv("TEMPLATES_GLOB", "templates/**/*.templ")
+	if matches, err := filepath.Glob(templatesGlob); err == nil && len(matches) > 0 {
+		router.LoadHTMLGlob(templatesGlob)
+		dashboard := handlers.NewDashboardHandler()
+		router.GET("/", dashboard.Index)
+	} else {
+		log.Printf("no HTML templates match %q; serving API only (set TEMPLATES_GLOB or run from the directory containing templates/)", templatesGlob)
+	}
+
+	metricsHandler := handlers.NewMetricsHandler(k8sService, promService, store, collector, notifiers, thresholds, readOnly, retention)
+	metricsHandler.SetEnrichConcurrency(getenvInt("NODE_ENRICH_CONCURRENCY", 0))
+	metricsHandler.SetPodEnrichPolicy(getenvInt("POD_ENRICH_LIMIT", 0), strings.Split(os.Getenv("POD_ENRICH_NAMESPACES"), ","))
+	metricsHandler.SetMaxStreamSubscribers(getenvInt("STREAM_MAX_SUBSCRIBERS", 0))
+	metricsHandler.StartHealthStream(getenvDuration("STREAM_INTERVAL", 10*time.Second))
+	router.GET("/api/version", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"version": buildVersion, "apiVersion": apiVersion})
+	})
+
+	v1 := router.Group("/api/" + apiVersion)
+	v1.Use(handlers.GzipMiddleware(handlers.DefaultGzipMinSize), handlers.EnvelopeMiddleware(apiVersion))
+	registerAPIRoutes(v1, metricsHandler)
+
+	// The unversioned /api prefix remains an alias of the current
+	// version; clients should migrate to /api/v1.
+	legacy := router.Group("/api")
+	legacy.Use(deprecationHeader(), handlers.GzipMiddleware(handlers.DefaultGzipMinSize))
+	registerAPIRoutes(legacy, metricsHandler)
+
+	// The health stream is registered outside the API groups: the gzip
+	// and envelope middleware buffer whole responses, which would stall
+	// server-sent events indefinitely.
+	router.GET("/api/"+apiVersion+"/stream", metricsHandler.StreamHealth)
+	router.GET("/api/stream", metricsHandler.StreamHealth)
+
+	router.GET("/healthz", func(c *gin.Context) {
+		c.JSON(http.StatusOK, gin.H{"status": "ok"})
+	})
+	router.GET("/readyz", func(c *gin.Context) {
+		if err := store.Ping(); err != nil {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "database unavailable"})
+			return
+		}
+		// Hold traffic until the first collection cycle has produced
+		// real data; a rolling deploy otherwise routes users to an
+		// instance that renders empty charts.
+		if collector != nil && !collector.Ready() {
+			c.JSON(http.StatusServiceUnavailable, gin.H{"status": "awaiting first collection"})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"status": "ready"})
+	})
+	router.GET("/metrics", gin.WrapH(promhttp.Handler()))
+
+	addr := net.JoinHostPort(bindAddr, port)
+	srv := &http.Server{Addr: addr, Handler: router}
+	go func() {
+		var err error
+		if tlsCert != "" {
+			log.Printf("kdash-monitor listening on %s (TLS)", addr)
+			err = srv.ListenAndServeTLS(tlsCert, tlsKey)
+		} else {
+			log.Printf("kdash-monitor listening on %s", addr)
+			err = srv.ListenAndServe()
+		}
+		if err != nil && err != http.ErrServerClosed {
+			log.Fatalf("server error: %v", err)
+		}
+	}()
+
+	if k8sService != nil {
+		hup := make(chan os.Signal, 1)
+		signal.Notify(hup, syscall.SIGHUP)
+		go func() {
+			for range hup {
+				log.Println("SIGHUP received, reloading cluster config")
+				if err := k8sService.ReloadConfigs(); err != nil {
+					log.Printf("config reload failed: %v", err)
+				}
+			}
+		}()
+	}
+
+	quit := make(chan os.Signal, 1)
+	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+	<-quit
+	log.Println("shutting down")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		log.Printf("forced shutdown: %v", err)
+	}
+}
+
+// registerAPIRoutes wires the API handlers onto a route group, so the
+// versioned prefix and its unversioned alias share one definition.
+func registerAPIRoutes(api *gin.RouterGroup, h *handlers.MetricsHandler) {
+	api.GET("/clusters", h.GetClusters)
+	api.GET("/clusters/health", h.GetClustersHealth)
+	api.GET("/pods", h.GetAllPods)
+	api.GET("/compare", h.CompareClusters)
+	api.GET("/images", h.GetImageUsage)
+	api.GET("/clusters/:name", h.GetClusterDetails)
+	api.GET("/clusters/:name/full", h.GetClusterFull)
+	api.GET("/clusters/:name/nodes", h.GetClusterNodes)
+	api.GET("/clusters/:name/nodes/:node/history", h.GetNodeHistory)
+	api.GET("/clusters/:name/nodes/:node/pods", h.GetNodePods)
+	api.POST("/clusters/:name/nodes/:node/cordon", h.CordonNode)
+	api.POST("/clusters/:name/nodes/:node/uncordon", h.UncordonNode)
+	api.POST("/clusters/:name/nodes/:node/drain", h.DrainNode)
+	api.GET("/clusters/:name/pods", h.GetClusterPods)
+	api.GET("/clusters/:name/pods/diff", h.GetClusterPodDiff)
+	api.GET("/clusters/:name/image-errors", h.GetImagePullErrors)
+	api.GET("/clusters/:name/top", h.GetClusterTop)
+	api.GET("/clusters/:name/pvcs", h.GetClusterPVCs)
+	api.GET("/clusters/:name/events", h.GetClusterEvents)
+	api.GET("/clusters/:name/deployments", h.GetClusterDeployments)
+	api.GET("/clusters/:name/daemonsets", h.GetClusterDaemonSets)
+	api.GET("/clusters/:name/statefulsets", h.GetClusterStatefulSets)
+	api.GET("/clusters/:name/jobs", h.GetClusterJobs)
+	api.GET("/clusters/:name/cronjobs", h.GetClusterCronJobs)
+	api.GET("/clusters/:name/ingresses", h.GetClusterIngresses)
+	api.GET("/clusters/:name/services", h.GetClusterServices)
+	api.GET("/clusters/:name/pods/:namespace/:pod", h.GetPodDetail)
+	api.GET("/clusters/:name/pods/:namespace/:pod/logs", h.GetPodLogs)
+	api.GET("/clusters/:name/history", h.GetClusterHistory)
+	api.GET("/clusters/:name/thresholds", h.GetClusterThresholds)
+	api.PUT("/clusters/:name/thresholds", h.PutClusterThresholds)
+	api.POST("/clusters/:name/alerts/resolve-all", h.ResolveAllAlerts)
+	api.GET("/clusters/:name/compare", h.GetClusterCompare)
+	api.GET("/clusters/:name/annotations", h.GetAnnotations)
+	api.POST("/clusters/:name/annotations", h.CreateAnnotation)
+	api.GET("/clusters/:name/uptime", h.GetClusterUptime)
+	api.POST("/clusters/:name/push", h.PushMetrics)
+	api.GET("/clusters/:name/promql", h.QueryPromQL)
+	api.GET("/clusters/:name/bundle", h.GetClusterBundle)
+	api.GET("/clusters/:name/transitions", h.GetClusterTransitions)
+	api.GET("/alerts", h.GetAlerts)
+	api.GET("/alerts/stats", h.GetAlertStats)
+	api.POST("/alerts/:id/resolve", h.ResolveAlert)
+	api.GET("/silences", h.GetSilences)
+	api.POST("/silences", h.CreateSilence)
+	api.DELETE("/silences/:id", h.DeleteSilence)
+	api.POST("/notifications/test", h.TestNotifications)
+	api.GET("/notifications/history", h.GetNotificationHistory)
+	api.GET("/config/clusters", h.GetClusterConfigs)
+	api.GET("/status", h.GetStatus)
+}
+
+// deprecationHeader flags responses served from the unversioned /api
+// alias so clients can discover the versioned prefix.
+func deprecationHeader() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", "</api/"+apiVersion+">; rel=\"successor-version\"")
+		c.Next()
+	}
+}
+
+// runRetentionCleanup periodically deletes stored data older than the
+// retention window.
+func runRetentionCleanup(store *storage.MetricsStore, retention time.Duration) {
+	ticker := time.NewTicker(time.Hour)
+	defer ticker.Stop()
+	for range ticker.C {
+		if err := store.Cleanup(time.Now().Add(-retention)); err != nil {
+			log.Printf("retention cleanup failed: %v", err)
+		}
+	}
+}
+
+// parseEscalationPolicy parses ALERT_ESCALATION, a comma-separated list
+// of kind=duration pairs like "cpu=30m,memory=1h".
+func parseEscalationPolicy(raw string) map[string]time.Duration {
+	if raw == "" {
+		return nil
+	}
+	policy := make(map[string]time.Duration)
+	for _, pair := range strings.Split(raw, ",") {
+		kind, value, ok := strings.Cut(strings.TrimSpace(pair), "=")
+		if !ok {
+			log.Printf("warning: ignoring malformed ALERT_ESCALATION entry %q", pair)
+			continue
+		}
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			log.Printf("warning: ignoring ALERT_ESCALATION entry %q: %v", pair, err)
+			continue
+		}
+		policy[kind] = d
+	}
+	return policy
+}
+
+// parseAlertRules parses ALERT_RULES, a comma-separated list of
+// sustained-condition rules like "cpu>80:5m" or "memory>90:10m:critical".
+// Each entry is metric>threshold:for[:severity]; severity defaults to
+// warning. Malformed entries are logged and skipped.
+func parseAlertRules(raw string) []services.EvalRule {
+	if raw == "" {
+		return nil
+	}
+	var rules []services.EvalRule
+	for _, entry := range strings.Split(raw, ",") {
+		entry = strings.TrimSpace(entry)
+		metric, rest, ok := strings.Cut(entry, ">")
+		if !ok {
+			log.Printf("warning: ignoring malformed ALERT_RULES entry %q", entry)
+			continue
+		}
+		parts := strings.Split(rest, ":")
+		if len(parts) < 2 || len(parts) > 3 {
+			log.Printf("warning: ignoring malformed ALERT_RULES entry %q", entry)
+			continue
+		}
+		threshold, err := strconv.ParseFloat(parts[0], 64)
+		if err != nil {
+			log.Printf("warning: ignoring ALERT_RULES entry %q: %v", entry, err)
+			continue
+		}
+		d, err := time.ParseDuration(parts[1])
+		if err != nil {
+			log.Printf("warning: ignoring ALERT_RULES entry %q: %v", entry, err)
+			continue
+		}
+		severity := models.SeverityWarning
+		if len(parts) == 3 && parts[2] != "" {
+			s := strings.ToLower(parts[2])
+			severity = models.Severity(strings.ToUpper(s[:1]) + s[1:])
+		}
+		rule := services.EvalRule{Metric: metric, Threshold: threshold, For: d, Severity: severity}
+		if err := rule.Validate(); err != nil {
+			log.Printf("warning: ignoring ALERT_RULES entry %q: %v", entry, err)
+			continue
+		}
+		rules = append(rules, rule)
+	}
+	return rules
+}
+
+// getenv returns an environment variable or a default.
+func getenv(key, fallback string) string {
+	if v := os.Getenv(key); v != "" {
+		return v
+	}
+	return fallback
+}
+
+// getenvInt parses an integer environment variable, falling back on
+// absence or parse failure.
+func getenvInt(key string, fallback int) int {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		log.Printf("warning: invalid %s=%q, using %d", key, v, fallback)
+		return fallback
+	}
+	return n
+}
+
+// getenvFloat parses a float environment variable, falling back on
+// absence or parse failure.
+func getenvFloat(key string, fallback float64) float64 {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		log.Printf("warning: invalid %s=%q, using %g", key, v, fallback)
+		return fallback
+	}
+	return f
+}
+
+// getenvDuration parses a duration environment variable, falling back
+// on absence or parse failure.
+func getenvDuration(key string, fallback time.Duration) time.Duration {
+	v := os.Getenv(key)
+	if v == "" {
+		return fallback
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Printf("warning: invalid %s=%q, using %s", key, v, fallback)
+		return fallback
+	}
+	return d
+}
@@ -0,0 +1,22 @@
+package handlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// DashboardHandler serves the HTML dashboard pages.
+type DashboardHandler struct{}
+
+// NewDashboardHandler returns a DashboardHandler.
+func NewDashboardHandler() *DashboardHandler {
+	return &DashboardHandler{}
+}
+
+// Index renders the main dashboard page.
+func (h *DashboardHandler) Index(c *gin.Context) {
+	c.HTML(http.StatusOK, "dashboard", gin.H{
+		"title": "kdash-monitor",
+	})
+}
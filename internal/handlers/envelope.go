@@ -0,0 +1,90 @@
+package handlers
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// EnvelopeMiddleware rewraps every JSON response in the versioned API's
+// envelope: successes become {"data": ..., "meta": {"apiVersion": ...}}
+// and errors become {"error": {"code": ..., "message": ...}} with a
+// stable machine-readable code. Applied only to /api/v1 so the
+// deprecated unversioned alias keeps its historical shapes.
+func EnvelopeMiddleware(version string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		w := &envelopeWriter{ResponseWriter: c.Writer}
+		c.Writer = w
+		c.Next()
+		c.Writer = w.ResponseWriter
+		w.flush(c, version)
+	}
+}
+
+// envelopeWriter buffers the handler's response body so the middleware
+// can rewrap it after the handler returns.
+type envelopeWriter struct {
+	gin.ResponseWriter
+	buf bytes.Buffer
+}
+
+func (w *envelopeWriter) Write(b []byte) (int, error) {
+	return w.buf.Write(b)
+}
+
+func (w *envelopeWriter) WriteString(s string) (int, error) {
+	return w.buf.WriteString(s)
+}
+
+// flush writes the enveloped body. Non-JSON and empty responses pass
+// through untouched.
+func (w *envelopeWriter) flush(c *gin.Context, version string) {
+	body := w.buf.Bytes()
+	if len(body) == 0 {
+		return
+	}
+	if !strings.Contains(w.Header().Get("Content-Type"), "application/json") {
+		c.Writer.Write(body)
+		return
+	}
+	status := w.Status()
+	var out []byte
+	if status >= http.StatusBadRequest {
+		message := strings.TrimSpace(string(body))
+		var legacy struct {
+			Error string `json:"error"`
+		}
+		if json.Unmarshal(body, &legacy) == nil && legacy.Error != "" {
+			message = legacy.Error
+		}
+		out, _ = json.Marshal(gin.H{"error": gin.H{"code": errorCode(status), "message": message}})
+	} else {
+		out, _ = json.Marshal(gin.H{"data": json.RawMessage(body), "meta": gin.H{"apiVersion": version}})
+	}
+	c.Writer.Write(out)
+}
+
+// errorCode maps an HTTP status onto the envelope's stable error code.
+func errorCode(status int) string {
+	switch status {
+	case http.StatusBadRequest:
+		return "bad_request"
+	case http.StatusUnauthorized:
+		return "unauthorized"
+	case http.StatusForbidden:
+		return "forbidden"
+	case http.StatusNotFound:
+		return "not_found"
+	case http.StatusConflict:
+		return "conflict"
+	case http.StatusTooManyRequests:
+		return "rate_limited"
+	case http.StatusServiceUnavailable:
+		return "unavailable"
+	default:
+		return "internal"
+	}
+}
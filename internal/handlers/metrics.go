@@ -0,0 +1,2306 @@
+package handlers
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	"github.com/rishabh998186/kdash-monitor/internal/models"
+	"github.com/rishabh998186/kdash-monitor/internal/notifications"
+	"github.com/rishabh998186/kdash-monitor/internal/services"
+	"github.com/rishabh998186/kdash-monitor/internal/storage"
+)
+
+// requestTimeout bounds the upstream work done on behalf of a single
+// API request.
+const requestTimeout = 30 * time.Second
+
+// MetricsHandler serves the JSON API over the cluster services and the
+// metrics store.
+type MetricsHandler struct {
+	k8sService  *services.KubernetesService
+	promService *services.PrometheusService
+	store       *storage.MetricsStore
+	collector   *services.MetricsCollector
+	notifiers   []notifications.Notifier
+	thresholds  models.AlertThresholds
+	readOnly    bool
+	retention   time.Duration
+	// enrichConcurrency bounds how many nodes are enriched from
+	// Prometheus at once; 0 means defaultEnrichConcurrency.
+	enrichConcurrency int
+	// podEnrichLimit caps how many pods a single request enriches with
+	// Prometheus usage; 0 enriches everything. podEnrichNamespaces, when
+	// non-empty, restricts enrichment (and the batched queries behind
+	// it) to those namespaces.
+	podEnrichLimit      int
+	podEnrichNamespaces map[string]bool
+	podDiffs            *podDiffTracker
+	streams             *streamBroker
+
+	// imageCache holds the last fleet-wide image report; rebuilding it
+	// means listing every pod in every cluster, so it is cached for
+	// imageReportTTL. Guarded by imageMu.
+	imageMu    sync.Mutex
+	imageCache *imageReport
+}
+
+// defaultEnrichConcurrency is the node-enrichment fan-out used when no
+// override is configured. Wide enough that a large cluster finishes
+// inside the request timeout, narrow enough not to hammer Prometheus.
+const defaultEnrichConcurrency = 8
+
+// SetEnrichConcurrency overrides the node-enrichment fan-out; values
+// below 1 are ignored.
+func (h *MetricsHandler) SetEnrichConcurrency(n int) {
+	if n >= 1 {
+		h.enrichConcurrency = n
+	}
+}
+
+// SetPodEnrichPolicy bounds per-pod usage enrichment for large
+// clusters. A cluster-wide "usage by pod" query against 5000 pods
+// returns 5000 series; scoping to namespaces shrinks the query itself,
+// and the limit bounds how many pods get annotated, with non-Running
+// pods kept first since those are the ones worth investigating. The
+// defaults (no limit, all namespaces) enrich everything, which is right
+// for small clusters.
+func (h *MetricsHandler) SetPodEnrichPolicy(limit int, namespaces []string) {
+	if limit > 0 {
+		h.podEnrichLimit = limit
+	}
+	for _, ns := range namespaces {
+		if ns = strings.TrimSpace(ns); ns != "" {
+			if h.podEnrichNamespaces == nil {
+				h.podEnrichNamespaces = make(map[string]bool)
+			}
+			h.podEnrichNamespaces[ns] = true
+		}
+	}
+}
+
+// NewMetricsHandler wires the API handlers over the given services.
+// retention caps how far back history queries may reach.
+func NewMetricsHandler(k8s *services.KubernetesService, prom *services.PrometheusService, store *storage.MetricsStore, collector *services.MetricsCollector, notifiers []notifications.Notifier, thresholds models.AlertThresholds, readOnly bool, retention time.Duration) *MetricsHandler {
+	return &MetricsHandler{
+		k8sService:  k8s,
+		promService: prom,
+		store:       store,
+		collector:   collector,
+		notifiers:   notifiers,
+		thresholds:  thresholds,
+		readOnly:    readOnly,
+		retention:   retention,
+		podDiffs:    newPodDiffTracker(),
+		streams:     newStreamBroker(),
+	}
+}
+
+// requestContext derives a bounded context from the incoming request.
+func requestContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(c.Request.Context(), requestTimeout)
+}
+
+// parseLabelFilters parses repeated ?label=key=value params into a map.
+func parseLabelFilters(c *gin.Context) (map[string]string, error) {
+	filters := make(map[string]string)
+	for _, raw := range c.QueryArray("label") {
+		key, value, ok := strings.Cut(raw, "=")
+		if !ok || key == "" {
+			return nil, &labelFilterError{raw}
+		}
+		filters[key] = value
+	}
+	return filters, nil
+}
+
+type labelFilterError struct{ raw string }
+
+func (e *labelFilterError) Error() string {
+	return "invalid label filter " + strconv.Quote(e.raw) + ", expected key=value"
+}
+
+// matchesLabels reports whether a cluster's labels satisfy every filter
+// (AND semantics).
+func matchesLabels(labels, filters map[string]string) bool {
+	for key, value := range filters {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// requireK8s reports whether the Kubernetes service is available,
+// answering 503 when startup left it nil so degraded mode returns a
+// clear error instead of panicking on a nil pointer.
+func (h *MetricsHandler) requireK8s(c *gin.Context) bool {
+	if h.k8sService == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "Kubernetes service unavailable"})
+		return false
+	}
+	return true
+}
+
+// GetClusters returns the status of every enabled cluster, optionally
+// filtered by ?label=key=value (repeatable, AND semantics).
+func (h *MetricsHandler) GetClusters(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	filters, err := parseLabelFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	// One grouped query covers every cluster's alert counts; a failure
+	// degrades the counts to zero rather than failing the listing.
+	alertCounts, err := h.store.CountActiveAlerts()
+	if err != nil {
+		log.Printf("failed to count active alerts: %v", err)
+	}
+
+	clusters := []models.Cluster{}
+	for _, cfg := range h.k8sService.GetConfigs() {
+		if !cfg.Enabled {
+			continue
+		}
+		if !matchesLabels(cfg.Labels, filters) {
+			continue
+		}
+		cluster := h.buildClusterSummary(ctx, cfg, "")
+		if counts, ok := alertCounts[cfg.Name]; ok {
+			cluster.ActiveAlerts = counts.Active
+			cluster.CriticalAlerts = counts.Critical
+		}
+		clusters = append(clusters, cluster)
+	}
+	c.JSON(http.StatusOK, gin.H{"clusters": clusters})
+}
+
+// GetClustersHealth serves the minimal health view from the collector's
+// cached state. It never queries an upstream, so frequent polling by
+// status pages is safe.
+func (h *MetricsHandler) GetClustersHealth(c *gin.Context) {
+	if h.collector == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "collector disabled"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"clusters": h.collector.Health()})
+}
+
+// buildClusterSummary assembles one cluster's live summary. agg
+// selects the cross-node aggregation for the CPU and memory figures
+// ("" or "avg" for the mean, "max", "p95", "p99"); the single-node
+// maxima are reported alongside either way.
+func (h *MetricsHandler) buildClusterSummary(ctx context.Context, cfg models.ClusterConfig, agg string) models.Cluster {
+	cluster := models.Cluster{
+		Name:        cfg.Name,
+		DisplayName: cfg.DisplayName,
+		Labels:      cfg.Labels,
+		Status:      models.StatusUnreachable,
+	}
+	if err := h.k8sService.CheckConnectivity(ctx, cfg.Name); err != nil {
+		// An unreachable cluster shows the age of its last good data
+		// rather than a "just now" that would hide the staleness.
+		cluster.LastUpdated = h.lastKnownUpdate(cfg.Name)
+		return cluster
+	}
+	cluster.Reachable = true
+	cluster.LastUpdated = time.Now()
+	cluster.ControlPlaneHealthy = h.k8sService.CheckControlPlane(ctx, cfg.Name) == nil
+
+	notReadyNodes := 0
+	if nodes, err := h.k8sService.GetNodes(ctx, cfg.Name, ""); err == nil {
+		cluster.NodeCount = len(nodes)
+		notReadyNodes = len(services.NotReadyNodes(nodes))
+	}
+	if summary, err := h.k8sService.GetPodSummary(ctx, cfg.Name); err == nil {
+		cluster.PodCount = summary.Total
+		cluster.FailedPods = summary.Failed
+		cluster.PendingPods = summary.Pending
+		cluster.PendingReasons = summary.PendingReasons
+	}
+	if cfg.PrometheusURL != "" {
+		var failed []string
+		if err := h.promService.CheckConnectivity(ctx, cfg.PrometheusURL); err == nil {
+			if cpu, err := h.clusterCPU(ctx, cfg.PrometheusURL, agg, cfg.PrometheusClusterLabel); err == nil {
+				cluster.CPUUsage = cpu
+			} else {
+				failed = append(failed, "cpu")
+				log.Printf("cpu usage query failed for %s: %v", cfg.Name, err)
+			}
+			if mem, err := h.clusterMemory(ctx, cfg.PrometheusURL, agg, cfg.PrometheusClusterLabel); err == nil {
+				cluster.MemoryUsage = mem
+			} else {
+				failed = append(failed, "memory")
+				log.Printf("memory usage query failed for %s: %v", cfg.Name, err)
+			}
+			// Hotspot figures are best-effort, like allocation pressure.
+			if cpuMax, err := h.promService.GetClusterCPUMax(ctx, cfg.PrometheusURL, cfg.PrometheusClusterLabel); err == nil {
+				cluster.CPUMax = cpuMax
+			}
+			if memMax, err := h.promService.GetClusterMemoryMax(ctx, cfg.PrometheusURL, cfg.PrometheusClusterLabel); err == nil {
+				cluster.MemoryMax = memMax
+			}
+			if disk, err := h.promService.GetDiskUsage(ctx, cfg.PrometheusURL, cfg.PrometheusClusterLabel); err == nil {
+				cluster.DiskUsage = disk
+			} else {
+				failed = append(failed, "disk")
+				log.Printf("disk usage query failed for %s: %v", cfg.Name, err)
+			}
+			// Allocation pressure needs kube-state-metrics; absence is
+			// common enough that failure is not worth a MetricsError.
+			if requested, err := h.promService.GetCPURequestedPercent(ctx, cfg.PrometheusURL, cfg.PrometheusClusterLabel); err == nil {
+				cluster.CPURequested = requested
+			}
+			// Same for apiserver latency, which needs the control
+			// plane's own metrics to be scraped.
+			if latency, err := h.promService.GetAPIServerLatencyP99(ctx, cfg.PrometheusURL, cfg.PrometheusClusterLabel); err == nil {
+				cluster.APIServerLatencyP99 = latency
+			}
+			if len(failed) > 0 {
+				cluster.MetricsError = strings.Join(failed, ", ") + " queries failed"
+			}
+		} else {
+			cluster.MetricsError = "prometheus unreachable"
+			log.Printf("prometheus connectivity check failed for %s: %v", cfg.Name, err)
+		}
+	}
+	cluster.Status = services.DetermineClusterStatus(h.thresholds, cluster.CPUUsage, cluster.MemoryUsage, cluster.DiskUsage, cluster.FailedPods, cluster.PodCount, notReadyNodes)
+	// Missing metrics must not read as a green cluster: a broken
+	// pipeline is at least a warning even when everything it can still
+	// see looks fine.
+	if cluster.MetricsError != "" && cluster.Status == models.StatusHealthy {
+		cluster.Status = models.StatusWarning
+	}
+	return cluster
+}
+
+// lastKnownUpdate returns the timestamp of a cluster's most recent
+// persisted snapshot — its last successful collection. Zero when the
+// cluster has never been collected.
+func (h *MetricsHandler) lastKnownUpdate(cluster string) time.Time {
+	if snap, err := h.store.GetLatestSnapshot(cluster); err == nil && snap != nil {
+		return snap.Timestamp
+	}
+	return time.Time{}
+}
+
+// clusterCPU dispatches between the default (possibly overridden)
+// average query and the aggregation variants.
+func (h *MetricsHandler) clusterCPU(ctx context.Context, baseURL, agg, scope string) (float64, error) {
+	if agg == "" || agg == "avg" {
+		return h.promService.GetCPUUsage(ctx, baseURL, scope)
+	}
+	return h.promService.GetCPUUsageAgg(ctx, baseURL, agg, scope)
+}
+
+// clusterMemory is the memory counterpart of clusterCPU.
+func (h *MetricsHandler) clusterMemory(ctx context.Context, baseURL, agg, scope string) (float64, error) {
+	if agg == "" || agg == "avg" {
+		return h.promService.GetMemoryUsage(ctx, baseURL, scope)
+	}
+	return h.promService.GetMemoryUsageAgg(ctx, baseURL, agg, scope)
+}
+
+// GetClusterDetails returns one cluster's summary. ?agg= selects the
+// cross-node aggregation for the CPU and memory figures (avg, max,
+// p95, p99), defaulting to the average.
+func (h *MetricsHandler) GetClusterDetails(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	agg := c.Query("agg")
+	if !services.ValidAggregation(agg) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid agg (want avg, max, p95, or p99)"})
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	cfg, ok := h.k8sService.GetConfig(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cluster not found"})
+		return
+	}
+	cluster := h.buildClusterSummary(ctx, cfg, agg)
+	if cluster.Reachable {
+		if version, err := h.k8sService.GetServerVersion(ctx, cfg.Name); err == nil {
+			cluster.ServerVersion = version
+		}
+	}
+	c.JSON(http.StatusOK, cluster)
+}
+
+// GetClusterFull returns a cluster's summary, nodes, pod summary, and
+// recent alerts in one response. It performs a single connectivity
+// check and fetches the parts concurrently, so the detail page needs
+// one round-trip instead of three.
+func (h *MetricsHandler) GetClusterFull(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	cfg, ok := h.k8sService.GetConfig(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cluster not found"})
+		return
+	}
+	cluster := models.Cluster{
+		Name:        cfg.Name,
+		DisplayName: cfg.DisplayName,
+		Labels:      cfg.Labels,
+		Status:      models.StatusUnreachable,
+	}
+	alerts, alertsErr := h.store.GetAlerts(cfg.Name, false, 100)
+	if alertsErr != nil {
+		alerts = nil
+	}
+	if err := h.k8sService.CheckConnectivity(ctx, cfg.Name); err != nil {
+		cluster.LastUpdated = h.lastKnownUpdate(cfg.Name)
+		c.JSON(http.StatusOK, gin.H{
+			"cluster": cluster,
+			"nodes":   []models.Node{},
+			"pods":    models.PodSummary{},
+			"alerts":  alerts,
+		})
+		return
+	}
+	cluster.Reachable = true
+	cluster.LastUpdated = time.Now()
+
+	var (
+		wg      sync.WaitGroup
+		nodes   []models.Node
+		summary models.PodSummary
+		version string
+	)
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		list, err := h.k8sService.GetNodes(ctx, cfg.Name, "")
+		if err != nil {
+			return
+		}
+		h.enrichNodes(ctx, cfg, list)
+		nodes = list
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if s, err := h.k8sService.GetPodSummary(ctx, cfg.Name); err == nil {
+			summary = *s
+		}
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if v, err := h.k8sService.GetServerVersion(ctx, cfg.Name); err == nil {
+			version = v
+		}
+	}()
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		if cfg.PrometheusURL == "" {
+			return
+		}
+		if cpu, err := h.promService.GetCPUUsage(ctx, cfg.PrometheusURL, cfg.PrometheusClusterLabel); err == nil {
+			cluster.CPUUsage = cpu
+		}
+		if mem, err := h.promService.GetMemoryUsage(ctx, cfg.PrometheusURL, cfg.PrometheusClusterLabel); err == nil {
+			cluster.MemoryUsage = mem
+		}
+		if disk, err := h.promService.GetDiskUsage(ctx, cfg.PrometheusURL, cfg.PrometheusClusterLabel); err == nil {
+			cluster.DiskUsage = disk
+		}
+	}()
+	wg.Wait()
+
+	cluster.ServerVersion = version
+	cluster.NodeCount = len(nodes)
+	cluster.PodCount = summary.Total
+	cluster.FailedPods = summary.Failed
+	cluster.PendingPods = summary.Pending
+	cluster.PendingReasons = summary.PendingReasons
+	cluster.Status = services.DetermineClusterStatus(h.thresholds, cluster.CPUUsage, cluster.MemoryUsage, cluster.DiskUsage, cluster.FailedPods, cluster.PodCount, len(services.NotReadyNodes(nodes)))
+
+	c.JSON(http.StatusOK, gin.H{
+		"cluster": cluster,
+		"nodes":   nodes,
+		"pods":    summary,
+		"alerts":  alerts,
+	})
+}
+
+// GetClusterNodes returns a cluster's nodes enriched with Prometheus
+// usage data where available, optionally narrowed by ?labelSelector=
+// (a Kubernetes label selector) and ?role= (a computed node role).
+func (h *MetricsHandler) GetClusterNodes(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	name := c.Param("name")
+	cfg, ok := h.k8sService.GetConfig(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cluster not found"})
+		return
+	}
+	nodes, err := h.k8sService.GetNodes(ctx, name, c.Query("labelSelector"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if role := c.Query("role"); role != "" {
+		nodes = filterNodesByRole(nodes, role)
+	}
+	h.enrichNodes(ctx, cfg, nodes)
+	c.JSON(http.StatusOK, gin.H{"nodes": nodes})
+}
+
+// filterNodesByRole keeps only nodes carrying the given role.
+func filterNodesByRole(nodes []models.Node, role string) []models.Node {
+	matched := make([]models.Node, 0, len(nodes))
+	for _, node := range nodes {
+		for _, r := range node.Roles {
+			if r == role {
+				matched = append(matched, node)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// enrichNodes fills per-node usage metrics from Prometheus in place,
+// leaving fields zero where no data is available. Nodes are enriched by
+// a bounded worker pool so a large cluster's tail doesn't starve inside
+// the request timeout — a serial loop left later nodes reading as 0%.
+func (h *MetricsHandler) enrichNodes(ctx context.Context, cfg models.ClusterConfig, nodes []models.Node) {
+	if cfg.PrometheusURL == "" {
+		return
+	}
+	workers := h.enrichConcurrency
+	if workers < 1 {
+		workers = defaultEnrichConcurrency
+	}
+	sem := make(chan struct{}, workers)
+	var wg sync.WaitGroup
+	for i := range nodes {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(node *models.Node) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			if cpu, err := h.promService.GetNodeCPUUsage(ctx, cfg.PrometheusURL, node.Name, cfg.PrometheusClusterLabel); err == nil {
+				node.CPUUsage = cpu
+			}
+			if mem, err := h.promService.GetNodeMemoryUsage(ctx, cfg.PrometheusURL, node.Name, cfg.PrometheusClusterLabel); err == nil {
+				node.MemoryUsage = mem
+			}
+			if disk, err := h.promService.GetNodeDiskUsage(ctx, cfg.PrometheusURL, node.Name, cfg.PrometheusClusterLabel); err == nil {
+				node.DiskUsage = disk
+			}
+			if rx, err := h.promService.GetNodeNetworkRx(ctx, cfg.PrometheusURL, node.Name, cfg.PrometheusClusterLabel); err == nil {
+				node.NetworkRx = rx
+			}
+			if tx, err := h.promService.GetNodeNetworkTx(ctx, cfg.PrometheusURL, node.Name, cfg.PrometheusClusterLabel); err == nil {
+				node.NetworkTx = tx
+			}
+		}(&nodes[i])
+	}
+	wg.Wait()
+}
+
+// CordonNode marks a node unschedulable.
+func (h *MetricsHandler) CordonNode(c *gin.Context) {
+	h.setNodeSchedulable(c, false)
+}
+
+// UncordonNode marks a node schedulable again.
+func (h *MetricsHandler) UncordonNode(c *gin.Context) {
+	h.setNodeSchedulable(c, true)
+}
+
+// setNodeSchedulable performs the cordon/uncordon patch behind the
+// read-only gate shared by all mutating actions.
+func (h *MetricsHandler) setNodeSchedulable(c *gin.Context, schedulable bool) {
+	if !h.requireK8s(c) {
+		return
+	}
+	if h.readOnly {
+		c.JSON(http.StatusForbidden, gin.H{"error": "server is in read-only mode"})
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	cluster, node := c.Param("name"), c.Param("node")
+	var err error
+	if schedulable {
+		err = h.k8sService.UncordonNode(ctx, cluster, node)
+	} else {
+		err = h.k8sService.CordonNode(ctx, cluster, node)
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"node": node, "schedulable": schedulable})
+}
+
+// DrainNode cordons a node and starts evicting its pods in the
+// background, returning 202 immediately. Progress is reported through
+// GET /api/status. The caller must pass ?confirm=true since draining
+// disrupts workloads.
+func (h *MetricsHandler) DrainNode(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	if h.readOnly {
+		c.JSON(http.StatusForbidden, gin.H{"error": "server is in read-only mode"})
+		return
+	}
+	if c.Query("confirm") != "true" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "draining evicts workloads; pass ?confirm=true to proceed"})
+		return
+	}
+	opts := services.DrainOptions{}
+	if raw := c.Query("gracePeriodSeconds"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed < 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid gracePeriodSeconds"})
+			return
+		}
+		opts.GracePeriodSeconds = &parsed
+	}
+	cluster, node := c.Param("name"), c.Param("node")
+	if err := h.k8sService.StartDrain(cluster, node, opts); err != nil {
+		c.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusAccepted, gin.H{
+		"node":   node,
+		"status": "draining",
+		"detail": "poll /api/status for progress",
+	})
+}
+
+// GetClusterPods returns a cluster's pods, optionally scoped by
+// ?namespace= and filtered by ?image= (substring match against
+// container images) and the ?youngerThan=/?olderThan= age bounds.
+func (h *MetricsHandler) GetClusterPods(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	youngerThan, olderThan, err := parseAgeFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	name := c.Param("name")
+	if !h.checkNamespaceParam(c, ctx, name) {
+		return
+	}
+	pods, err := h.k8sService.GetPods(ctx, name, c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if image := c.Query("image"); image != "" {
+		pods = filterPodsByImage(pods, image)
+	}
+	pods = filterPodsByAge(pods, youngerThan, olderThan)
+	if cfg, ok := h.k8sService.GetConfig(name); ok {
+		h.enrichPods(ctx, cfg, c.Query("namespace"), pods)
+	}
+	c.JSON(http.StatusOK, gin.H{"pods": pods})
+}
+
+// GetNodePods returns the pods scheduled on one node enriched with
+// per-pod usage, largest consumers first, so a hot node's detail view
+// answers "what's eating this node" directly.
+func (h *MetricsHandler) GetNodePods(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	name := c.Param("name")
+	node := c.Param("node")
+	cfg, ok := h.k8sService.GetConfig(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cluster not found"})
+		return
+	}
+	pods, err := h.k8sService.GetPodsOnNode(ctx, name, node)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	h.enrichPods(ctx, cfg, "", pods)
+	// CPU is the usual reason a node runs hot; memory breaks ties for
+	// pods Prometheus has no CPU data on.
+	sort.SliceStable(pods, func(i, j int) bool {
+		if pods[i].CPUUsage != pods[j].CPUUsage {
+			return pods[i].CPUUsage > pods[j].CPUUsage
+		}
+		return pods[i].MemoryUsage > pods[j].MemoryUsage
+	})
+	c.JSON(http.StatusOK, gin.H{"node": node, "pods": pods})
+}
+
+// imagePullReasons are the container waiting reasons that indicate a
+// failed image pull rather than some other startup problem.
+var imagePullReasons = map[string]bool{
+	"ImagePullBackOff": true,
+	"ErrImagePull":     true,
+	"InvalidImageName": true,
+}
+
+// GetImagePullErrors returns pods whose containers cannot pull their
+// image, grouped by image so a registry outage or a bad tag reads as
+// one line ("23 pods can't pull app:v2") instead of a pod list to
+// correlate by hand. Optionally scoped by ?namespace=.
+func (h *MetricsHandler) GetImagePullErrors(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	name := c.Param("name")
+	pods, err := h.k8sService.GetPods(ctx, name, c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	type pullError struct {
+		Image    string   `json:"image"`
+		Reason   string   `json:"reason"`
+		Message  string   `json:"message,omitempty"`
+		Pods     int      `json:"pods"`
+		Affected []string `json:"affected"`
+	}
+	byImage := make(map[string]*pullError)
+	for _, pod := range pods {
+		for _, container := range pod.Containers {
+			if container.State != "waiting" || !imagePullReasons[container.Reason] {
+				continue
+			}
+			e := byImage[container.Image]
+			if e == nil {
+				e = &pullError{Image: container.Image, Reason: container.Reason}
+				byImage[container.Image] = e
+			}
+			if e.Message == "" {
+				e.Message = container.Message
+			}
+			e.Pods++
+			e.Affected = append(e.Affected, pod.Namespace+"/"+pod.Name)
+		}
+	}
+	errors := make([]pullError, 0, len(byImage))
+	for _, e := range byImage {
+		sort.Strings(e.Affected)
+		errors = append(errors, *e)
+	}
+	sort.Slice(errors, func(i, j int) bool {
+		if errors[i].Pods != errors[j].Pods {
+			return errors[i].Pods > errors[j].Pods
+		}
+		return errors[i].Image < errors[j].Image
+	})
+	c.JSON(http.StatusOK, gin.H{"imageErrors": errors})
+}
+
+// GetPodDetail returns the full view of one pod: containers,
+// conditions, QoS class, recent events, and live usage when the
+// cluster has Prometheus configured.
+func (h *MetricsHandler) GetPodDetail(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	name := c.Param("name")
+	namespace := c.Param("namespace")
+	pod := c.Param("pod")
+	detail, err := h.k8sService.GetPodDetail(ctx, name, namespace, pod)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if cfg, ok := h.k8sService.GetConfig(name); ok && cfg.PrometheusURL != "" {
+		if cpu, err := h.promService.GetPodCPUUsage(ctx, cfg.PrometheusURL, namespace, pod, cfg.PrometheusClusterLabel); err == nil {
+			detail.CPUUsage = cpu
+			if detail.CPULimit > 0 {
+				detail.CPUUsageOfLimit = 100 * cpu / detail.CPULimit
+			}
+		}
+		if mem, err := h.promService.GetPodMemoryUsage(ctx, cfg.PrometheusURL, namespace, pod, cfg.PrometheusClusterLabel); err == nil {
+			detail.MemoryUsage = mem
+			if detail.MemoryLimit > 0 {
+				detail.MemoryUsageOfLimit = 100 * mem / detail.MemoryLimit
+			}
+		}
+		// An absent throttling series (no CPU limit set) stays nil so
+		// the UI can render "n/a" instead of a false 0%.
+		if throttle, err := h.promService.GetPodCPUThrottling(ctx, cfg.PrometheusURL, namespace, pod, cfg.PrometheusClusterLabel); err == nil {
+			detail.CPUThrottlePercent = &throttle
+		}
+	}
+	c.JSON(http.StatusOK, detail)
+}
+
+// enrichPods fills per-pod usage and usage-vs-limit ratios from two
+// batched Prometheus queries, leaving fields zero where no data exists.
+// The enrichment policy (SetPodEnrichPolicy) may leave some pods
+// unenriched on very large clusters; those are still returned, just
+// without usage figures.
+func (h *MetricsHandler) enrichPods(ctx context.Context, cfg models.ClusterConfig, namespace string, pods []models.Pod) {
+	if cfg.PrometheusURL == "" {
+		return
+	}
+	targets := h.enrichTargets(pods)
+	if len(targets) == 0 {
+		return
+	}
+	// With a namespace policy and an unscoped request, query the
+	// configured namespaces individually instead of the whole cluster —
+	// that, not the annotation loop, is where the cost lives.
+	queryNamespaces := []string{namespace}
+	if namespace == "" && len(h.podEnrichNamespaces) > 0 {
+		queryNamespaces = queryNamespaces[:0]
+		for ns := range h.podEnrichNamespaces {
+			queryNamespaces = append(queryNamespaces, ns)
+		}
+	}
+	cpuByPod := make(map[string]float64)
+	memByPod := make(map[string]float64)
+	restartsByPod := make(map[string]float64)
+	for _, ns := range queryNamespaces {
+		if m, err := h.promService.GetPodCPUUsageByPod(ctx, cfg.PrometheusURL, ns, cfg.PrometheusClusterLabel); err == nil {
+			mergeUsage(cpuByPod, m)
+		}
+		if m, err := h.promService.GetPodMemoryUsageByPod(ctx, cfg.PrometheusURL, ns, cfg.PrometheusClusterLabel); err == nil {
+			mergeUsage(memByPod, m)
+		}
+		if m, err := h.promService.GetPodRestartRatesByPod(ctx, cfg.PrometheusURL, ns, cfg.PrometheusClusterLabel); err == nil {
+			mergeUsage(restartsByPod, m)
+		}
+	}
+	for _, i := range targets {
+		// The batched queries key by "namespace/pod" so same-named pods
+		// in different namespaces don't share usage figures.
+		key := pods[i].Namespace + "/" + pods[i].Name
+		if cpu, ok := cpuByPod[key]; ok {
+			pods[i].CPUUsage = cpu
+			if pods[i].CPULimit > 0 {
+				pods[i].CPUUsageOfLimit = 100 * cpu / pods[i].CPULimit
+			}
+		}
+		if mem, ok := memByPod[key]; ok {
+			pods[i].MemoryUsage = mem
+			if pods[i].MemoryLimit > 0 {
+				pods[i].MemoryUsageOfLimit = 100 * mem / pods[i].MemoryLimit
+			}
+		}
+		if rate, ok := restartsByPod[key]; ok {
+			pods[i].RestartRate = rate
+		}
+	}
+}
+
+// enrichTargets selects which pods the enrichment policy covers,
+// returning their indexes. When the cap forces a choice, non-Running
+// pods win their slots first — an operator scanning a huge cluster
+// cares about the broken pods' usage, not the healthy tail's.
+func (h *MetricsHandler) enrichTargets(pods []models.Pod) []int {
+	targets := make([]int, 0, len(pods))
+	for i := range pods {
+		if len(h.podEnrichNamespaces) > 0 && !h.podEnrichNamespaces[pods[i].Namespace] {
+			continue
+		}
+		targets = append(targets, i)
+	}
+	if h.podEnrichLimit <= 0 || len(targets) <= h.podEnrichLimit {
+		return targets
+	}
+	sort.SliceStable(targets, func(a, b int) bool {
+		return pods[targets[a]].Status != "Running" && pods[targets[b]].Status == "Running"
+	})
+	return targets[:h.podEnrichLimit]
+}
+
+// mergeUsage folds one batched query result into the accumulator.
+func mergeUsage(dst, src map[string]float64) {
+	for pod, v := range src {
+		dst[pod] = v
+	}
+}
+
+// filterPodsByImage keeps only pods with at least one container whose
+// image contains the given substring. Image is not a Kubernetes field
+// selector, so this has to be matched server-side after listing.
+func filterPodsByImage(pods []models.Pod, image string) []models.Pod {
+	matched := make([]models.Pod, 0, len(pods))
+	for _, pod := range pods {
+		for _, container := range pod.Containers {
+			if strings.Contains(container.Image, image) {
+				matched = append(matched, pod)
+				break
+			}
+		}
+	}
+	return matched
+}
+
+// checkNamespaceParam validates an optional ?namespace= against DNS
+// label syntax and the cluster's actual namespaces, writing a 400 for
+// malformed names and a 404 for well-formed but absent ones — so a
+// typoed namespace yields a clear error instead of a confusing empty
+// pod list. Returns false when a response has been written.
+func (h *MetricsHandler) checkNamespaceParam(c *gin.Context, ctx context.Context, cluster string) bool {
+	ns := c.Query("namespace")
+	if ns == "" || ns == "*" {
+		return true
+	}
+	if errs := validation.IsDNS1123Label(ns); len(errs) > 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": fmt.Sprintf("invalid namespace %q: %s", ns, errs[0])})
+		return false
+	}
+	// Existence is best-effort: a failed lookup should not take down a
+	// listing that would otherwise work.
+	if exists, err := h.k8sService.NamespaceExists(ctx, cluster, ns); err == nil && !exists {
+		c.JSON(http.StatusNotFound, gin.H{"error": fmt.Sprintf("namespace %q not found", ns)})
+		return false
+	}
+	return true
+}
+
+// parseAgeFilters reads the optional ?youngerThan= and ?olderThan=
+// duration parameters, for watching freshly rolled-out pods ("younger
+// than 5m") or finding long-lived ones.
+func parseAgeFilters(c *gin.Context) (youngerThan, olderThan time.Duration, err error) {
+	if raw := c.Query("youngerThan"); raw != "" {
+		if youngerThan, err = time.ParseDuration(raw); err != nil {
+			return 0, 0, fmt.Errorf("invalid youngerThan: %v", err)
+		}
+	}
+	if raw := c.Query("olderThan"); raw != "" {
+		if olderThan, err = time.ParseDuration(raw); err != nil {
+			return 0, 0, fmt.Errorf("invalid olderThan: %v", err)
+		}
+	}
+	return youngerThan, olderThan, nil
+}
+
+// filterPodsByAge keeps pods whose real age satisfies the given
+// bounds; a zero bound is ignored.
+func filterPodsByAge(pods []models.Pod, youngerThan, olderThan time.Duration) []models.Pod {
+	if youngerThan == 0 && olderThan == 0 {
+		return pods
+	}
+	matched := make([]models.Pod, 0, len(pods))
+	for _, pod := range pods {
+		age := time.Duration(pod.AgeSeconds) * time.Second
+		if youngerThan > 0 && age >= youngerThan {
+			continue
+		}
+		if olderThan > 0 && age <= olderThan {
+			continue
+		}
+		matched = append(matched, pod)
+	}
+	return matched
+}
+
+// allPodsResultCap bounds the merged result of the fleet-wide pod
+// listing.
+const allPodsResultCap = 2000
+
+// GetAllPods lists pods across every enabled cluster concurrently,
+// filtered by ?name= (substring), ?namespace=, ?status=, ?cluster=,
+// ?image= (substring match against container images), and the
+// ?youngerThan=/?olderThan= age bounds.
+// Each pod is tagged with its source cluster, and the merged result is
+// capped at allPodsResultCap entries.
+func (h *MetricsHandler) GetAllPods(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	nameFilter := c.Query("name")
+	namespaceFilter := c.Query("namespace")
+	statusFilter := c.Query("status")
+	clusterFilter := c.Query("cluster")
+	imageFilter := c.Query("image")
+	youngerThan, olderThan, err := parseAgeFilters(c)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	var (
+		mu   sync.Mutex
+		wg   sync.WaitGroup
+		pods []models.Pod
+	)
+	for _, cfg := range h.k8sService.GetConfigs() {
+		if !cfg.Enabled {
+			continue
+		}
+		if clusterFilter != "" && cfg.Name != clusterFilter {
+			continue
+		}
+		wg.Add(1)
+		go func(cfg models.ClusterConfig) {
+			defer wg.Done()
+			clusterPods, err := h.k8sService.GetPods(ctx, cfg.Name, namespaceFilter)
+			if err != nil {
+				return
+			}
+			if imageFilter != "" {
+				clusterPods = filterPodsByImage(clusterPods, imageFilter)
+			}
+			clusterPods = filterPodsByAge(clusterPods, youngerThan, olderThan)
+			matched := make([]models.Pod, 0, len(clusterPods))
+			for _, pod := range clusterPods {
+				if nameFilter != "" && !strings.Contains(pod.Name, nameFilter) {
+					continue
+				}
+				if statusFilter != "" && pod.Status != statusFilter {
+					continue
+				}
+				pod.Cluster = cfg.Name
+				matched = append(matched, pod)
+			}
+			mu.Lock()
+			pods = append(pods, matched...)
+			mu.Unlock()
+		}(cfg)
+	}
+	wg.Wait()
+
+	truncated := false
+	if len(pods) > allPodsResultCap {
+		pods = pods[:allPodsResultCap]
+		truncated = true
+	}
+	c.JSON(http.StatusOK, gin.H{"pods": pods, "truncated": truncated})
+}
+
+// imageReportTTL is how long a computed fleet-wide image report is
+// served from cache; scanning every pod across every cluster is too
+// expensive to repeat per request.
+const imageReportTTL = 5 * time.Minute
+
+// imageResultCap bounds how many distinct images the report lists.
+const imageResultCap = 200
+
+// imageUsage is one image's footprint across the fleet.
+type imageUsage struct {
+	Image    string `json:"image"`
+	Registry string `json:"registry"`
+	Pods     int    `json:"pods"`
+	Clusters int    `json:"clusters"`
+}
+
+// imageReport is the cached fleet-wide image aggregation.
+type imageReport struct {
+	images     []imageUsage
+	registries map[string]int
+	truncated  bool
+	generated  time.Time
+}
+
+// GetImageUsage returns how many pods and clusters run each container
+// image across the fleet, plus per-registry pod totals — the numbers
+// needed to size a pull-through cache and see how much pull traffic
+// goes to which registry. Most-used images first, capped at
+// imageResultCap and cached for imageReportTTL.
+func (h *MetricsHandler) GetImageUsage(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	h.imageMu.Lock()
+	cached := h.imageCache
+	h.imageMu.Unlock()
+	if cached == nil || time.Since(cached.generated) > imageReportTTL {
+		ctx, cancel := requestContext(c)
+		defer cancel()
+		cached = h.buildImageReport(ctx)
+		h.imageMu.Lock()
+		h.imageCache = cached
+		h.imageMu.Unlock()
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"images":      cached.images,
+		"registries":  cached.registries,
+		"truncated":   cached.truncated,
+		"generatedAt": cached.generated,
+	})
+}
+
+// buildImageReport scans every enabled cluster's pods and groups their
+// container images.
+func (h *MetricsHandler) buildImageReport(ctx context.Context) *imageReport {
+	type tally struct {
+		pods     int
+		clusters map[string]bool
+	}
+	var (
+		mu      sync.Mutex
+		wg      sync.WaitGroup
+		byImage = make(map[string]*tally)
+	)
+	for _, cfg := range h.k8sService.GetConfigs() {
+		if !cfg.Enabled {
+			continue
+		}
+		wg.Add(1)
+		go func(cfg models.ClusterConfig) {
+			defer wg.Done()
+			pods, err := h.k8sService.GetPods(ctx, cfg.Name, "")
+			if err != nil {
+				return
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			for _, pod := range pods {
+				for _, container := range pod.Containers {
+					t := byImage[container.Image]
+					if t == nil {
+						t = &tally{clusters: make(map[string]bool)}
+						byImage[container.Image] = t
+					}
+					t.pods++
+					t.clusters[cfg.Name] = true
+				}
+			}
+		}(cfg)
+	}
+	wg.Wait()
+
+	report := &imageReport{
+		registries: make(map[string]int),
+		generated:  time.Now(),
+	}
+	for image, t := range byImage {
+		report.registries[imageRegistry(image)] += t.pods
+		report.images = append(report.images, imageUsage{
+			Image:    image,
+			Registry: imageRegistry(image),
+			Pods:     t.pods,
+			Clusters: len(t.clusters),
+		})
+	}
+	sort.Slice(report.images, func(i, j int) bool {
+		if report.images[i].Pods != report.images[j].Pods {
+			return report.images[i].Pods > report.images[j].Pods
+		}
+		return report.images[i].Image < report.images[j].Image
+	})
+	if len(report.images) > imageResultCap {
+		report.images = report.images[:imageResultCap]
+		report.truncated = true
+	}
+	return report
+}
+
+// imageRegistry extracts the registry hostname from an image
+// reference. A first segment without a dot, colon, or "localhost" is a
+// Docker Hub path component, not a host.
+func imageRegistry(image string) string {
+	host, _, ok := strings.Cut(image, "/")
+	if !ok || (!strings.ContainsAny(host, ".:") && host != "localhost") {
+		return "docker.io"
+	}
+	return host
+}
+
+// GetClusterTop returns the highest-consuming pods for a resource,
+// selected by ?resource=cpu|memory (default cpu) and capped by ?limit=
+// (default 10).
+func (h *MetricsHandler) GetClusterTop(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	cfg, ok := h.k8sService.GetConfig(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cluster not found"})
+		return
+	}
+	if cfg.PrometheusURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cluster has no prometheus configured"})
+		return
+	}
+	resource := c.DefaultQuery("resource", "cpu")
+	limit := 10
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed <= 0 || parsed > 100 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit, expected 1-100"})
+			return
+		}
+		limit = parsed
+	}
+	top, err := h.promService.GetTopPods(ctx, cfg.PrometheusURL, resource, limit, cfg.PrometheusClusterLabel)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"resource": resource, "pods": top})
+}
+
+// GetClusterPVCs returns a cluster's persistent volume claims,
+// optionally scoped by ?namespace= and enriched with percent-full from
+// Prometheus kubelet volume stats where available.
+func (h *MetricsHandler) GetClusterPVCs(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	name := c.Param("name")
+	cfg, ok := h.k8sService.GetConfig(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cluster not found"})
+		return
+	}
+	pvcs, err := h.k8sService.GetPersistentVolumeClaims(ctx, name, c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if cfg.PrometheusURL != "" {
+		for i := range pvcs {
+			if used, err := h.promService.GetPVCUsedPercent(ctx, cfg.PrometheusURL, pvcs[i].Namespace, pvcs[i].Name, cfg.PrometheusClusterLabel); err == nil {
+				pvcs[i].UsedPercent = used
+			}
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{"pvcs": pvcs})
+}
+
+// GetClusterEvents returns a cluster's recent events.
+func (h *MetricsHandler) GetClusterEvents(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	events, err := h.k8sService.GetEvents(ctx, c.Param("name"), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"events": events})
+}
+
+// GetClusterServices returns a cluster's services, optionally scoped by
+// ?namespace=.
+func (h *MetricsHandler) GetClusterServices(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	services, err := h.k8sService.GetServices(ctx, c.Param("name"), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"services": services})
+}
+
+// GetClusterIngresses returns a cluster's ingresses, optionally scoped
+// by ?namespace=.
+func (h *MetricsHandler) GetClusterIngresses(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	ingresses, err := h.k8sService.GetIngresses(ctx, c.Param("name"), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"ingresses": ingresses})
+}
+
+// GetClusterDeployments returns a cluster's deployments.
+func (h *MetricsHandler) GetClusterDeployments(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	deployments, err := h.k8sService.GetDeployments(ctx, c.Param("name"), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deployments": deployments})
+}
+
+// GetClusterDaemonSets returns a cluster's daemon sets.
+func (h *MetricsHandler) GetClusterDaemonSets(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	daemonsets, err := h.k8sService.GetDaemonSets(ctx, c.Param("name"), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"daemonsets": daemonsets})
+}
+
+// GetClusterStatefulSets returns a cluster's stateful sets.
+func (h *MetricsHandler) GetClusterStatefulSets(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	statefulsets, err := h.k8sService.GetStatefulSets(ctx, c.Param("name"), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"statefulsets": statefulsets})
+}
+
+// GetClusterJobs returns a cluster's batch jobs.
+func (h *MetricsHandler) GetClusterJobs(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	jobs, err := h.k8sService.GetJobs(ctx, c.Param("name"), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"jobs": jobs})
+}
+
+// GetClusterCronJobs returns a cluster's cron jobs.
+func (h *MetricsHandler) GetClusterCronJobs(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	cronjobs, err := h.k8sService.GetCronJobs(ctx, c.Param("name"), c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"cronjobs": cronjobs})
+}
+
+// GetPodLogs returns the tail of a pod's logs; ?previous=true fetches
+// the last terminated container instance's logs instead.
+func (h *MetricsHandler) GetPodLogs(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	tailLines := int64(100)
+	if raw := c.Query("tailLines"); raw != "" {
+		parsed, err := strconv.ParseInt(raw, 10, 64)
+		if err != nil || parsed <= 0 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid tailLines"})
+			return
+		}
+		tailLines = parsed
+	}
+	previous := c.Query("previous") == "true"
+	logs, err := h.k8sService.GetPodLogs(ctx, c.Param("name"), c.Param("namespace"), c.Param("pod"), c.Query("container"), tailLines, previous)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if previous {
+			// No previous instance is a caller-addressable condition,
+			// not a server fault.
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"logs": logs})
+}
+
+// defaultHistoryWindow is how far back the history endpoint reaches
+// when no range is requested.
+const defaultHistoryWindow = 24 * time.Hour
+
+// GetClusterHistory returns a cluster's stored snapshots. The window is
+// selected with ?duration= (a Go duration, plus a d suffix for days,
+// capped at the retention setting) or pinpointed with ?from=&to=
+// RFC3339 timestamps; it defaults to the last 24 hours.
+func (h *MetricsHandler) GetClusterHistory(c *gin.Context) {
+	name := c.Param("name")
+
+	if fromRaw, toRaw := c.Query("from"), c.Query("to"); fromRaw != "" || toRaw != "" {
+		from, err := time.Parse(time.RFC3339, fromRaw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from timestamp, expected RFC3339"})
+			return
+		}
+		to := time.Now()
+		if toRaw != "" {
+			if to, err = time.Parse(time.RFC3339, toRaw); err != nil {
+				c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to timestamp, expected RFC3339"})
+				return
+			}
+		}
+		if !to.After(from) {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "to must be after from"})
+			return
+		}
+		snapshots, err := h.store.GetSnapshotsRange(name, from, to)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			return
+		}
+		c.JSON(http.StatusOK, gin.H{"snapshots": snapshots})
+		return
+	}
+
+	window := defaultHistoryWindow
+	if raw := c.Query("duration"); raw != "" {
+		parsed, err := parseHistoryDuration(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		window = parsed
+	}
+	if h.retention > 0 && window > h.retention {
+		window = h.retention
+	}
+	snapshots, err := h.store.GetSnapshots(name, time.Now().Add(-window))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"snapshots": snapshots})
+}
+
+// parseHistoryDuration parses a Go duration, additionally accepting a
+// whole-day d suffix like 7d.
+func parseHistoryDuration(raw string) (time.Duration, error) {
+	if days, ok := strings.CutSuffix(raw, "d"); ok {
+		n, err := strconv.Atoi(days)
+		if err != nil || n <= 0 {
+			return 0, &historyDurationError{raw}
+		}
+		return time.Duration(n) * 24 * time.Hour, nil
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0, &historyDurationError{raw}
+	}
+	return d, nil
+}
+
+type historyDurationError struct{ raw string }
+
+func (e *historyDurationError) Error() string {
+	return "invalid duration " + strconv.Quote(e.raw) + ", expected a Go duration like 6h or a day count like 7d"
+}
+
+// GetNodeHistory returns one node's stored snapshots over a window
+// selected with ?duration= (defaulting to the last 24 hours), for
+// charting a single node's usage over time.
+func (h *MetricsHandler) GetNodeHistory(c *gin.Context) {
+	window := defaultHistoryWindow
+	if raw := c.Query("duration"); raw != "" {
+		parsed, err := parseHistoryDuration(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		window = parsed
+	}
+	if h.retention > 0 && window > h.retention {
+		window = h.retention
+	}
+	snapshots, err := h.store.GetNodeSnapshots(c.Param("name"), c.Param("node"), time.Now().Add(-window))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"snapshots": snapshots})
+}
+
+// GetClusterCompare returns the stored snapshots nearest to two points
+// in time alongside the metric deltas between them, for "now vs an hour
+// ago" style comparisons.
+func (h *MetricsHandler) GetClusterCompare(c *gin.Context) {
+	name := c.Param("name")
+	from, err := time.Parse(time.RFC3339, c.Query("from"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid from timestamp, expected RFC3339"})
+		return
+	}
+	to, err := time.Parse(time.RFC3339, c.Query("to"))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid to timestamp, expected RFC3339"})
+		return
+	}
+	fromSnap, err := h.store.GetSnapshotNearest(name, from)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	toSnap, err := h.store.GetSnapshotNearest(name, to)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if fromSnap == nil || toSnap == nil {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no snapshots stored for cluster " + name})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"from": fromSnap,
+		"to":   toSnap,
+		"delta": gin.H{
+			"cpuUsage":    toSnap.CPUUsage - fromSnap.CPUUsage,
+			"memoryUsage": toSnap.MemoryUsage - fromSnap.MemoryUsage,
+			"diskUsage":   toSnap.DiskUsage - fromSnap.DiskUsage,
+			"nodeCount":   toSnap.NodeCount - fromSnap.NodeCount,
+			"podCount":    toSnap.PodCount - fromSnap.PodCount,
+			"failedPods":  toSnap.FailedPods - fromSnap.FailedPods,
+			"pendingPods": toSnap.PendingPods - fromSnap.PendingPods,
+		},
+	})
+}
+
+// CompareClusters returns two clusters' live summaries side by side
+// with computed deltas, for parity checks between environments —
+// "does staging mirror prod". Deployments shared by namespace/name are
+// checked for image skew so a version drift shows up without eyeballing
+// two workload lists.
+func (h *MetricsHandler) CompareClusters(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	a := c.Query("a")
+	b := c.Query("b")
+	if a == "" || b == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a and b query parameters are required"})
+		return
+	}
+	if a == b {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "a and b must name different clusters"})
+		return
+	}
+	cfgA, ok := h.k8sService.GetConfig(a)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cluster not found: " + a})
+		return
+	}
+	cfgB, ok := h.k8sService.GetConfig(b)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cluster not found: " + b})
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	var (
+		sumA, sumB models.Cluster
+		depA, depB []models.Deployment
+		wg         sync.WaitGroup
+	)
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		sumA = h.buildClusterSummary(ctx, cfgA, "")
+		depA, _ = h.k8sService.GetDeployments(ctx, a, "")
+	}()
+	go func() {
+		defer wg.Done()
+		sumB = h.buildClusterSummary(ctx, cfgB, "")
+		depB, _ = h.k8sService.GetDeployments(ctx, b, "")
+	}()
+	wg.Wait()
+
+	c.JSON(http.StatusOK, gin.H{
+		"a": sumA,
+		"b": sumB,
+		"delta": gin.H{
+			"cpuUsage":    sumB.CPUUsage - sumA.CPUUsage,
+			"memoryUsage": sumB.MemoryUsage - sumA.MemoryUsage,
+			"diskUsage":   sumB.DiskUsage - sumA.DiskUsage,
+			"nodeCount":   sumB.NodeCount - sumA.NodeCount,
+			"podCount":    sumB.PodCount - sumA.PodCount,
+			"failedPods":  sumB.FailedPods - sumA.FailedPods,
+			"pendingPods": sumB.PendingPods - sumA.PendingPods,
+		},
+		"deployments": compareDeployments(depA, depB),
+	})
+}
+
+// compareDeployments diffs two deployment lists by namespace/name:
+// which workloads exist on only one side, and which shared ones run
+// different images.
+func compareDeployments(depA, depB []models.Deployment) gin.H {
+	key := func(d models.Deployment) string { return d.Namespace + "/" + d.Name }
+	byKeyA := make(map[string]models.Deployment, len(depA))
+	for _, d := range depA {
+		byKeyA[key(d)] = d
+	}
+	onlyInA := []string{}
+	onlyInB := []string{}
+	imageDiffs := []gin.H{}
+	for _, db := range depB {
+		da, shared := byKeyA[key(db)]
+		if !shared {
+			onlyInB = append(onlyInB, key(db))
+			continue
+		}
+		delete(byKeyA, key(db))
+		if !equalImages(da.Images, db.Images) {
+			imageDiffs = append(imageDiffs, gin.H{
+				"name":      db.Name,
+				"namespace": db.Namespace,
+				"a":         da.Images,
+				"b":         db.Images,
+			})
+		}
+	}
+	for k := range byKeyA {
+		onlyInA = append(onlyInA, k)
+	}
+	sort.Strings(onlyInA)
+	sort.Strings(onlyInB)
+	sort.Slice(imageDiffs, func(i, j int) bool {
+		return imageDiffs[i]["namespace"].(string)+"/"+imageDiffs[i]["name"].(string) <
+			imageDiffs[j]["namespace"].(string)+"/"+imageDiffs[j]["name"].(string)
+	})
+	return gin.H{
+		"onlyInA":    onlyInA,
+		"onlyInB":    onlyInB,
+		"imageDiffs": imageDiffs,
+	}
+}
+
+// equalImages compares image lists order-insensitively; container
+// order is not version skew.
+func equalImages(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	sortedA := append([]string(nil), a...)
+	sortedB := append([]string(nil), b...)
+	sort.Strings(sortedA)
+	sort.Strings(sortedB)
+	for i := range sortedA {
+		if sortedA[i] != sortedB[i] {
+			return false
+		}
+	}
+	return true
+}
+
+// defaultUptimeWindow is how far back the uptime report reaches when
+// no range is requested.
+const defaultUptimeWindow = 30 * 24 * time.Hour
+
+// GetClusterUptime derives an availability report from the stored
+// snapshots: the share of the window spent in each status plus the
+// count and total duration of critical episodes. The window is selected
+// with ?duration= (capped at the retention setting) and defaults to the
+// last 30 days. Each snapshot's status is recomputed against the
+// cluster's current thresholds, so the report reflects today's
+// definition of healthy rather than whatever was configured at the
+// time.
+func (h *MetricsHandler) GetClusterUptime(c *gin.Context) {
+	name := c.Param("name")
+	window := defaultUptimeWindow
+	if raw := c.Query("duration"); raw != "" {
+		parsed, err := parseHistoryDuration(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		window = parsed
+	}
+	if h.retention > 0 && window > h.retention {
+		window = h.retention
+	}
+	snapshots, err := h.store.GetSnapshots(name, time.Now().Add(-window))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if len(snapshots) < 2 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "not enough stored history for an uptime report"})
+		return
+	}
+	thresholds := h.thresholds
+	if override, err := h.store.GetClusterThresholds(name); err == nil && override != nil {
+		thresholds = override.Thresholds
+	}
+
+	// Each snapshot's status holds until the next snapshot; gaps in
+	// collection are attributed to whatever status preceded them.
+	durations := make(map[string]time.Duration)
+	criticalEpisodes := 0
+	var criticalTotal time.Duration
+	prev := ""
+	for i := 0; i < len(snapshots)-1; i++ {
+		snap := snapshots[i]
+		status := services.DetermineClusterStatus(thresholds, snap.CPUUsage, snap.MemoryUsage, snap.DiskUsage, snap.FailedPods, snap.PodCount, 0)
+		gap := snapshots[i+1].Timestamp.Sub(snap.Timestamp)
+		durations[status] += gap
+		if status == models.StatusCritical {
+			criticalTotal += gap
+			if prev != models.StatusCritical {
+				criticalEpisodes++
+			}
+		}
+		prev = status
+	}
+
+	observed := snapshots[len(snapshots)-1].Timestamp.Sub(snapshots[0].Timestamp)
+	statuses := gin.H{}
+	for status, d := range durations {
+		statuses[status] = gin.H{
+			"durationSeconds": d.Seconds(),
+			"percent":         100 * float64(d) / float64(observed),
+		}
+	}
+	c.JSON(http.StatusOK, gin.H{
+		"cluster":         name,
+		"from":            snapshots[0].Timestamp,
+		"to":              snapshots[len(snapshots)-1].Timestamp,
+		"observedSeconds": observed.Seconds(),
+		"statuses":        statuses,
+		"critical": gin.H{
+			"episodes":     criticalEpisodes,
+			"totalSeconds": criticalTotal.Seconds(),
+		},
+	})
+}
+
+// GetClusterTransitions returns the ordered history of a cluster's
+// status changes.
+func (h *MetricsHandler) GetClusterTransitions(c *gin.Context) {
+	transitions, err := h.store.GetTransitions(c.Param("name"), 0)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"transitions": transitions})
+}
+
+// GetAlerts returns alerts, optionally filtered by ?cluster= and
+// including resolved ones with ?resolved=true.
+func (h *MetricsHandler) GetAlerts(c *gin.Context) {
+	includeResolved := c.Query("resolved") == "true"
+	alerts, err := h.store.GetAlerts(c.Query("cluster"), includeResolved, 100)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"alerts": alerts})
+}
+
+// Defaults for the alert statistics view: a month of daily buckets.
+const (
+	defaultAlertStatsWindow = 30 * 24 * time.Hour
+	defaultAlertStatsBucket = 24 * time.Hour
+)
+
+// GetAlertStats returns alert counts bucketed over time by severity,
+// selected with ?duration= (capped at the retention setting) and
+// ?bucket=, defaulting to 30 days of daily buckets. This backs the
+// "are things getting noisier" trend chart on the alerts page.
+func (h *MetricsHandler) GetAlertStats(c *gin.Context) {
+	window := time.Duration(defaultAlertStatsWindow)
+	if raw := c.Query("duration"); raw != "" {
+		parsed, err := parseHistoryDuration(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		window = parsed
+	}
+	if h.retention > 0 && window > h.retention {
+		window = h.retention
+	}
+	bucket := time.Duration(defaultAlertStatsBucket)
+	if raw := c.Query("bucket"); raw != "" {
+		parsed, err := parseHistoryDuration(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		bucket = parsed
+	}
+	stats, err := h.store.GetAlertStats(time.Now().Add(-window), bucket)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"stats": stats, "bucketSeconds": int64(bucket.Seconds())})
+}
+
+// ResolveAlert marks a single alert resolved.
+func (h *MetricsHandler) ResolveAlert(c *gin.Context) {
+	if h.readOnly {
+		c.JSON(http.StatusForbidden, gin.H{"error": "server is in read-only mode"})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid alert id"})
+		return
+	}
+	if err := h.store.ResolveAlert(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"resolved": id})
+}
+
+// GetClusterThresholds returns a cluster's effective alert thresholds
+// and whether they come from a stored override or the defaults.
+func (h *MetricsHandler) GetClusterThresholds(c *gin.Context) {
+	override, err := h.store.GetClusterThresholds(c.Param("name"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	if override != nil {
+		c.JSON(http.StatusOK, gin.H{"thresholds": override.Thresholds, "source": "override"})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"thresholds": h.thresholds, "source": "default"})
+}
+
+// PutClusterThresholds stores a per-cluster threshold override. The
+// collector picks it up on its next cycle.
+func (h *MetricsHandler) PutClusterThresholds(c *gin.Context) {
+	if h.readOnly {
+		c.JSON(http.StatusForbidden, gin.H{"error": "server is in read-only mode"})
+		return
+	}
+	var thresholds models.AlertThresholds
+	if err := c.ShouldBindJSON(&thresholds); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err := thresholds.Validate(); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	override := &models.ClusterThresholds{Cluster: c.Param("name"), Thresholds: thresholds}
+	if err := h.store.SaveClusterThresholds(override); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"thresholds": thresholds, "source": "override"})
+}
+
+// ResolveAllAlerts marks every active alert for a cluster resolved,
+// optionally only those matching ?severity=, for post-incident cleanup.
+func (h *MetricsHandler) ResolveAllAlerts(c *gin.Context) {
+	if h.readOnly {
+		c.JSON(http.StatusForbidden, gin.H{"error": "server is in read-only mode"})
+		return
+	}
+	severity := models.Severity(c.Query("severity"))
+	if severity != "" && severity.Rank() < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown severity " + strconv.Quote(string(severity))})
+		return
+	}
+	resolved, err := h.store.ResolveAllForCluster(c.Param("name"), severity)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"resolved": resolved})
+}
+
+// GetSilences lists the silence rules currently in effect.
+func (h *MetricsHandler) GetSilences(c *gin.Context) {
+	silences, err := h.store.GetActiveSilences(time.Now())
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"silences": silences})
+}
+
+// CreateSilence stores a new silence rule. At least one matcher field
+// must be set so a mistyped request cannot mute all alerting.
+func (h *MetricsHandler) CreateSilence(c *gin.Context) {
+	if h.readOnly {
+		c.JSON(http.StatusForbidden, gin.H{"error": "server is in read-only mode"})
+		return
+	}
+	var req struct {
+		Cluster   string     `json:"cluster"`
+		Kind      string     `json:"kind"`
+		Severity  string     `json:"severity"`
+		Comment   string     `json:"comment"`
+		ExpiresAt *time.Time `json:"expiresAt"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if req.Cluster == "" && req.Kind == "" && req.Severity == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "at least one of cluster, kind, severity is required"})
+		return
+	}
+	if req.Severity != "" && models.Severity(req.Severity).Rank() < 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "unknown severity " + strconv.Quote(req.Severity)})
+		return
+	}
+	if req.ExpiresAt != nil && req.ExpiresAt.Before(time.Now()) {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "expiresAt is in the past"})
+		return
+	}
+	silence := &models.Silence{
+		Cluster:   req.Cluster,
+		Kind:      req.Kind,
+		Severity:  models.Severity(req.Severity),
+		Comment:   req.Comment,
+		ExpiresAt: req.ExpiresAt,
+		CreatedAt: time.Now(),
+	}
+	if err := h.store.SaveSilence(silence); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, silence)
+}
+
+// DeleteSilence removes a silence rule by id.
+func (h *MetricsHandler) DeleteSilence(c *gin.Context) {
+	if h.readOnly {
+		c.JSON(http.StatusForbidden, gin.H{"error": "server is in read-only mode"})
+		return
+	}
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid silence id"})
+		return
+	}
+	if err := h.store.DeleteSilence(uint(id)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"deleted": id})
+}
+
+// CreateAnnotation marks a point on a cluster's timeline, typically
+// called by CI/CD to record a deployment. Timestamp defaults to now so
+// a pipeline can fire-and-forget at deploy time.
+func (h *MetricsHandler) CreateAnnotation(c *gin.Context) {
+	if h.readOnly {
+		c.JSON(http.StatusForbidden, gin.H{"error": "server is in read-only mode"})
+		return
+	}
+	var req struct {
+		Text      string     `json:"text"`
+		Type      string     `json:"type"`
+		Timestamp *time.Time `json:"timestamp"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if strings.TrimSpace(req.Text) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "annotation text is required"})
+		return
+	}
+	annotation := &models.MetricAnnotation{
+		Cluster:   c.Param("name"),
+		Text:      req.Text,
+		Type:      req.Type,
+		Timestamp: time.Now(),
+	}
+	if req.Timestamp != nil {
+		annotation.Timestamp = *req.Timestamp
+	}
+	if err := h.store.SaveAnnotation(annotation); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusCreated, annotation)
+}
+
+// bundleSnapshotWindow bounds how much snapshot history a state bundle
+// carries: enough recent trend for a support ticket without dumping
+// the whole retention period.
+const bundleSnapshotWindow = 24 * time.Hour
+
+// GetClusterBundle assembles everything known about a cluster right
+// now — summary, nodes, pod summary, deployments, recent alerts and
+// snapshots, config — into one downloadable JSON document, for support
+// tickets and offline analysis. Sub-fetches run concurrently and
+// degrade to empty sections rather than failing the bundle; the config
+// section is redacted the same way the config endpoint is.
+func (h *MetricsHandler) GetClusterBundle(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	cfg, ok := h.k8sService.GetConfig(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cluster not found"})
+		return
+	}
+
+	now := time.Now()
+	cluster := h.buildClusterSummary(ctx, cfg, "")
+	var (
+		wg          sync.WaitGroup
+		nodes       []models.Node
+		deployments []models.Deployment
+		version     string
+	)
+	if cluster.Reachable {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if list, err := h.k8sService.GetNodes(ctx, cfg.Name, ""); err == nil {
+				nodes = list
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if list, err := h.k8sService.GetDeployments(ctx, cfg.Name, ""); err == nil {
+				deployments = list
+			}
+		}()
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if v, err := h.k8sService.GetServerVersion(ctx, cfg.Name); err == nil {
+				version = v
+			}
+		}()
+	}
+	alerts, err := h.store.GetAlerts(cfg.Name, true, 100)
+	if err != nil {
+		alerts = nil
+	}
+	snapshots, err := h.store.GetSnapshots(cfg.Name, now.Add(-bundleSnapshotWindow))
+	if err != nil {
+		snapshots = nil
+	}
+	wg.Wait()
+
+	cluster.ServerVersion = version
+	c.Header("Content-Disposition",
+		fmt.Sprintf(`attachment; filename="%s-bundle-%s.json"`, cfg.Name, now.UTC().Format("20060102-150405")))
+	c.JSON(http.StatusOK, gin.H{
+		"generatedAt": now,
+		"cluster":     cluster,
+		"config":      sanitizeClusterConfig(cfg),
+		"nodes":       nodes,
+		"deployments": deployments,
+		"alerts":      alerts,
+		"snapshots":   snapshots,
+	})
+}
+
+// QueryPromQL runs an ad-hoc PromQL query against a cluster's
+// configured Prometheus, so power users can reuse kdash-monitor's
+// connectivity instead of arranging their own access. Only the
+// configured endpoint is reachable — the cluster name selects the URL,
+// never the request — and the raw samples come back unshaped.
+func (h *MetricsHandler) QueryPromQL(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	cfg, ok := h.k8sService.GetConfig(c.Param("name"))
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cluster not found"})
+		return
+	}
+	if cfg.PrometheusURL == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "cluster has no Prometheus configured"})
+		return
+	}
+	query := c.Query("query")
+	if strings.TrimSpace(query) == "" {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "query parameter is required"})
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+	result, err := h.promService.Query(ctx, cfg.PrometheusURL, query)
+	if err != nil {
+		c.JSON(http.StatusBadGateway, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"cluster": cfg.Name, "query": query, "result": result})
+}
+
+// PushMetrics ingests a snapshot pushed by an agent inside a cluster
+// the dashboard cannot poll (firewalled, outbound-only). The payload
+// carries the same fields a collection cycle would gather, and the
+// request must present the cluster's configured push token, either as
+// a bearer token or in X-Push-Token.
+func (h *MetricsHandler) PushMetrics(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	if h.collector == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "metrics collector not running"})
+		return
+	}
+	name := c.Param("name")
+	cfg, ok := h.k8sService.GetConfig(name)
+	if !ok {
+		c.JSON(http.StatusNotFound, gin.H{"error": "cluster not found"})
+		return
+	}
+	if cfg.PushToken == "" {
+		c.JSON(http.StatusForbidden, gin.H{"error": "push ingestion is not enabled for this cluster"})
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(pushToken(c)), []byte(cfg.PushToken)) != 1 {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid push token"})
+		return
+	}
+	var req struct {
+		CPUUsage    float64    `json:"cpuUsage"`
+		MemoryUsage float64    `json:"memoryUsage"`
+		DiskUsage   float64    `json:"diskUsage"`
+		NodeCount   int        `json:"nodeCount"`
+		PodCount    int        `json:"podCount"`
+		FailedPods  int        `json:"failedPods"`
+		PendingPods int        `json:"pendingPods"`
+		Timestamp   *time.Time `json:"timestamp"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	snap := &models.MetricSnapshot{
+		CPUUsage:    req.CPUUsage,
+		MemoryUsage: req.MemoryUsage,
+		DiskUsage:   req.DiskUsage,
+		NodeCount:   req.NodeCount,
+		PodCount:    req.PodCount,
+		FailedPods:  req.FailedPods,
+		PendingPods: req.PendingPods,
+	}
+	if req.Timestamp != nil {
+		snap.Timestamp = *req.Timestamp
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+	h.collector.IngestSnapshot(ctx, name, snap)
+	c.JSON(http.StatusAccepted, gin.H{"status": "accepted", "cluster": name})
+}
+
+// pushToken extracts the push credential from a request, preferring
+// the Authorization bearer token over the X-Push-Token header.
+func pushToken(c *gin.Context) string {
+	if auth := c.GetHeader("Authorization"); strings.HasPrefix(auth, "Bearer ") {
+		return strings.TrimPrefix(auth, "Bearer ")
+	}
+	return c.GetHeader("X-Push-Token")
+}
+
+// GetAnnotations returns a cluster's timeline annotations over a
+// window selected with ?duration= (defaulting to the last 24 hours),
+// matching the history endpoint so markers line up with the chart.
+func (h *MetricsHandler) GetAnnotations(c *gin.Context) {
+	window := defaultHistoryWindow
+	if raw := c.Query("duration"); raw != "" {
+		parsed, err := parseHistoryDuration(raw)
+		if err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+			return
+		}
+		window = parsed
+	}
+	if h.retention > 0 && window > h.retention {
+		window = h.retention
+	}
+	annotations, err := h.store.GetAnnotations(c.Param("name"), time.Now().Add(-window))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"annotations": annotations})
+}
+
+// GetNotificationHistory returns the notification delivery audit
+// trail, newest first, optionally filtered by ?cluster= and capped by
+// ?limit= (default 100).
+func (h *MetricsHandler) GetNotificationHistory(c *gin.Context) {
+	limit := 100
+	if raw := c.Query("limit"); raw != "" {
+		parsed, err := strconv.Atoi(raw)
+		if err != nil || parsed < 1 {
+			c.JSON(http.StatusBadRequest, gin.H{"error": "invalid limit, expected a positive integer"})
+			return
+		}
+		limit = parsed
+	}
+	logs, err := h.store.GetNotificationLogs(c.Query("cluster"), limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"history": logs})
+}
+
+// GetClusterConfigs returns the full configured cluster set, including
+// disabled clusters, with credentials redacted. This backs the settings
+// view, which needs configuration rather than derived status.
+func (h *MetricsHandler) GetClusterConfigs(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	configs := h.k8sService.GetConfigs()
+	sanitized := make([]models.ClusterConfig, 0, len(configs))
+	for _, cfg := range configs {
+		sanitized = append(sanitized, sanitizeClusterConfig(cfg))
+	}
+	c.JSON(http.StatusOK, gin.H{"clusters": sanitized})
+}
+
+// sanitizeClusterConfig strips anything credential-bearing from a
+// cluster config before it leaves the API. Today that is userinfo
+// embedded in the Prometheus URL; any field added to ClusterConfig that
+// can carry a secret must be redacted here too.
+func sanitizeClusterConfig(cfg models.ClusterConfig) models.ClusterConfig {
+	if cfg.PrometheusURL != "" {
+		if u, err := url.Parse(cfg.PrometheusURL); err == nil && u.User != nil {
+			u.User = nil
+			cfg.PrometheusURL = u.String()
+		}
+	}
+	return cfg
+}
+
+// TestNotifications pushes a clearly marked synthetic alert through the
+// configured notifiers (optionally just ?channel=) and reports
+// per-channel success or failure, so a new channel can be verified
+// without forcing a real alert.
+func (h *MetricsHandler) TestNotifications(c *gin.Context) {
+	if h.readOnly {
+		c.JSON(http.StatusForbidden, gin.H{"error": "server is in read-only mode"})
+		return
+	}
+	if len(h.notifiers) == 0 {
+		c.JSON(http.StatusBadRequest, gin.H{"error": "no notification channels configured"})
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	now := time.Now()
+	// Critical so minimum-severity filters on any channel let it
+	// through; the message leaves no doubt it is synthetic.
+	alert := models.Alert{
+		Cluster:   "kdash-monitor",
+		Kind:      "test",
+		Severity:  models.SeverityCritical,
+		Message:   "[TEST] This is a test notification from kdash-monitor. No action is required.",
+		Count:     1,
+		Timestamp: now,
+		FirstSeen: now,
+		LastSeen:  now,
+	}
+	channel := c.Query("channel")
+	results := gin.H{}
+	for _, n := range h.notifiers {
+		if channel != "" && n.Name() != channel {
+			continue
+		}
+		if err := n.Notify(ctx, alert); err != nil {
+			results[n.Name()] = err.Error()
+			continue
+		}
+		results[n.Name()] = "ok"
+	}
+	if len(results) == 0 {
+		c.JSON(http.StatusNotFound, gin.H{"error": "no notification channel named " + strconv.Quote(channel)})
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"results": results})
+}
+
+// GetStatus reports the collector's per-cluster status.
+func (h *MetricsHandler) GetStatus(c *gin.Context) {
+	resp := gin.H{}
+	if h.collector == nil {
+		resp["collector"] = "disabled"
+	} else {
+		resp["clusters"] = h.collector.Status()
+	}
+	if h.k8sService != nil {
+		if drains := h.k8sService.DrainStatuses(); len(drains) > 0 {
+			resp["drains"] = drains
+		}
+	}
+	resp["streamSubscribers"] = h.streams.count()
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+
+	"github.com/rishabh998186/kdash-monitor/internal/tracing"
+)
+
+// TracingMiddleware opens a span per request and threads its context
+// through so service-level spans nest underneath. With no exporter
+// configured the global tracer is a no-op.
+func TracingMiddleware() gin.HandlerFunc {
+	tracer := otel.Tracer(tracing.TracerName)
+	return func(c *gin.Context) {
+		ctx, span := tracer.Start(c.Request.Context(), c.Request.Method+" "+c.FullPath())
+		defer span.End()
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+		span.SetAttributes(attribute.Int("http.status_code", c.Writer.Status()))
+	}
+}
+
+// DefaultGzipMinSize is the smallest response body, in bytes, worth
+// compressing.
+const DefaultGzipMinSize = 1024
+
+// GzipMiddleware compresses responses for clients that send
+// Accept-Encoding: gzip. Bodies smaller than minSize are sent as-is,
+// and streaming responses (text/event-stream) bypass compression
+// entirely so they are never buffered.
+func GzipMiddleware(minSize int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !strings.Contains(c.GetHeader("Accept-Encoding"), "gzip") {
+			c.Next()
+			return
+		}
+		gw := &gzipResponseWriter{
+			ResponseWriter: c.Writer,
+			minSize:        minSize,
+			status:         http.StatusOK,
+		}
+		c.Writer = gw
+		c.Next()
+		gw.close()
+	}
+}
+
+// gzipResponseWriter buffers the response body until it can decide
+// whether compression is worthwhile. Streaming content types switch to
+// passthrough mode and are forwarded unbuffered.
+type gzipResponseWriter struct {
+	gin.ResponseWriter
+	minSize     int
+	status      int
+	wroteHeader bool
+	passthrough bool
+	buf         []byte
+}
+
+func (w *gzipResponseWriter) WriteHeader(status int) {
+	if w.passthrough || w.wroteHeader {
+		w.ResponseWriter.WriteHeader(status)
+		return
+	}
+	w.status = status
+	if isStreamingContentType(w.Header().Get("Content-Type")) {
+		w.passthrough = true
+		w.wroteHeader = true
+		w.ResponseWriter.WriteHeader(status)
+	}
+}
+
+func (w *gzipResponseWriter) Write(b []byte) (int, error) {
+	if !w.passthrough && isStreamingContentType(w.Header().Get("Content-Type")) {
+		w.passthrough = true
+		if !w.wroteHeader {
+			w.wroteHeader = true
+			w.ResponseWriter.WriteHeader(w.status)
+		}
+	}
+	if w.passthrough {
+		return w.ResponseWriter.Write(b)
+	}
+	w.buf = append(w.buf, b...)
+	return len(b), nil
+}
+
+func (w *gzipResponseWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// close flushes the buffered body, compressed when it crosses the
+// minimum size.
+func (w *gzipResponseWriter) close() {
+	if w.passthrough {
+		return
+	}
+	if len(w.buf) < w.minSize {
+		w.ResponseWriter.WriteHeader(w.status)
+		w.ResponseWriter.Write(w.buf)
+		return
+	}
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Add("Vary", "Accept-Encoding")
+	w.Header().Del("Content-Length")
+	w.ResponseWriter.WriteHeader(w.status)
+	zw := gzip.NewWriter(w.ResponseWriter)
+	zw.Write(w.buf)
+	zw.Close()
+}
+
+// isStreamingContentType reports whether a content type must never be
+// buffered or compressed.
+func isStreamingContentType(contentType string) bool {
+	return strings.HasPrefix(contentType, "text/event-stream")
+}
@@ -0,0 +1,167 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// podDiffTTL is how long a pod snapshot token stays redeemable. Long
+// enough to cover a rollout being watched, short enough that the
+// tracker doesn't accumulate forgotten baselines.
+const podDiffTTL = 30 * time.Minute
+
+// podDiffTracker remembers recent pod listings keyed by an opaque token
+// so consecutive calls to the diff endpoint can be compared in memory,
+// without persisting per-pod history.
+type podDiffTracker struct {
+	mu        sync.Mutex
+	snapshots map[string]*podDiffSnapshot
+	nextID    uint64
+}
+
+type podDiffSnapshot struct {
+	cluster string
+	taken   time.Time
+	pods    map[string]podDiffEntry
+}
+
+// podDiffEntry is the slice of pod state the diff cares about.
+type podDiffEntry struct {
+	status   string
+	restarts int32
+	node     string
+}
+
+func newPodDiffTracker() *podDiffTracker {
+	return &podDiffTracker{snapshots: make(map[string]*podDiffSnapshot)}
+}
+
+// save stores a snapshot and returns its token, pruning expired entries
+// while it holds the lock.
+func (t *podDiffTracker) save(snap *podDiffSnapshot) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	cutoff := time.Now().Add(-podDiffTTL)
+	for token, old := range t.snapshots {
+		if old.taken.Before(cutoff) {
+			delete(t.snapshots, token)
+		}
+	}
+	t.nextID++
+	token := strconv.FormatUint(t.nextID, 36) + "-" + strconv.FormatInt(snap.taken.UnixNano(), 36)
+	t.snapshots[token] = snap
+	return token
+}
+
+// take removes and returns the snapshot for a token, or nil when the
+// token is unknown or has expired.
+func (t *podDiffTracker) take(token string) *podDiffSnapshot {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	snap := t.snapshots[token]
+	if snap == nil || time.Since(snap.taken) > podDiffTTL {
+		delete(t.snapshots, token)
+		return nil
+	}
+	delete(t.snapshots, token)
+	return snap
+}
+
+// GetClusterPodDiff reports what changed in a cluster's pod list since
+// a previous call. The first call (no ?token=) establishes a baseline
+// and returns a token; passing that token back returns the pods added,
+// removed, restarted, or status-changed since, plus a fresh token for
+// the next round. Tokens are single-use and expire after 30 minutes.
+func (h *MetricsHandler) GetClusterPodDiff(c *gin.Context) {
+	if !h.requireK8s(c) {
+		return
+	}
+	ctx, cancel := requestContext(c)
+	defer cancel()
+
+	name := c.Param("name")
+	pods, err := h.k8sService.GetPods(ctx, name, c.Query("namespace"))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	current := &podDiffSnapshot{
+		cluster: name,
+		taken:   time.Now(),
+		pods:    make(map[string]podDiffEntry, len(pods)),
+	}
+	for _, p := range pods {
+		current.pods[p.Namespace+"/"+p.Name] = podDiffEntry{
+			status:   p.Status,
+			restarts: p.Restarts,
+			node:     p.Node,
+		}
+	}
+
+	token := c.Query("token")
+	if token == "" {
+		c.JSON(http.StatusOK, gin.H{
+			"token": h.podDiffs.save(current),
+			"pods":  len(pods),
+		})
+		return
+	}
+	prev := h.podDiffs.take(token)
+	if prev == nil || prev.cluster != name {
+		c.JSON(http.StatusNotFound, gin.H{"error": "unknown or expired snapshot token"})
+		return
+	}
+
+	added := []gin.H{}
+	changed := []gin.H{}
+	for _, p := range pods {
+		key := p.Namespace + "/" + p.Name
+		old, ok := prev.pods[key]
+		if !ok {
+			added = append(added, gin.H{"namespace": p.Namespace, "name": p.Name, "status": p.Status, "node": p.Node})
+			continue
+		}
+		restartDelta := p.Restarts - old.restarts
+		if old.status == p.Status && restartDelta == 0 {
+			continue
+		}
+		changed = append(changed, gin.H{
+			"namespace":    p.Namespace,
+			"name":         p.Name,
+			"from":         old.status,
+			"to":           p.Status,
+			"restartDelta": restartDelta,
+		})
+	}
+	removed := []gin.H{}
+	for key, old := range prev.pods {
+		if _, ok := current.pods[key]; ok {
+			continue
+		}
+		namespace, podName := splitPodKey(key)
+		removed = append(removed, gin.H{"namespace": namespace, "name": podName, "lastStatus": old.status})
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"token":   h.podDiffs.save(current),
+		"since":   prev.taken,
+		"added":   added,
+		"removed": removed,
+		"changed": changed,
+	})
+}
+
+// splitPodKey undoes the namespace/name key used by the diff maps.
+func splitPodKey(key string) (namespace, name string) {
+	for i := 0; i < len(key); i++ {
+		if key[i] == '/' {
+			return key[:i], key[i+1:]
+		}
+	}
+	return "", key
+}
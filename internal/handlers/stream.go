@@ -0,0 +1,154 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+)
+
+var (
+	streamSubscribers = promauto.NewGauge(prometheus.GaugeOpts{
+		Name: "kdash_stream_subscribers",
+		Help: "Number of connected health stream subscribers.",
+	})
+	streamDroppedEvents = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "kdash_stream_dropped_events_total",
+		Help: "Number of stream events dropped because a subscriber's buffer was full.",
+	})
+)
+
+// DefaultMaxStreamSubscribers caps concurrent stream connections when
+// no override is configured, so runaway dashboard tabs cannot exhaust
+// the server.
+const DefaultMaxStreamSubscribers = 100
+
+// streamSubscriberBuffer is the per-subscriber event buffer. A slow
+// client fills it and loses events rather than ever blocking the
+// publisher; health snapshots are self-contained, so a dropped one is
+// superseded by the next anyway.
+const streamSubscriberBuffer = 16
+
+// streamBroker fans published events out to subscribers with
+// drop-on-full semantics and a hard cap on subscriber count.
+type streamBroker struct {
+	mu   sync.Mutex
+	subs map[chan []byte]struct{}
+	max  int
+}
+
+func newStreamBroker() *streamBroker {
+	return &streamBroker{
+		subs: make(map[chan []byte]struct{}),
+		max:  DefaultMaxStreamSubscribers,
+	}
+}
+
+// subscribe registers a new subscriber channel, or reports false when
+// the cap is reached.
+func (b *streamBroker) subscribe() (chan []byte, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.subs) >= b.max {
+		return nil, false
+	}
+	ch := make(chan []byte, streamSubscriberBuffer)
+	b.subs[ch] = struct{}{}
+	streamSubscribers.Set(float64(len(b.subs)))
+	return ch, true
+}
+
+// unsubscribe removes a subscriber.
+func (b *streamBroker) unsubscribe(ch chan []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.subs, ch)
+	streamSubscribers.Set(float64(len(b.subs)))
+}
+
+// publish delivers an event to every subscriber that has buffer room,
+// dropping it for those that don't.
+func (b *streamBroker) publish(event []byte) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for ch := range b.subs {
+		select {
+		case ch <- event:
+		default:
+			streamDroppedEvents.Inc()
+		}
+	}
+}
+
+// count returns the number of connected subscribers.
+func (b *streamBroker) count() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return len(b.subs)
+}
+
+// SetMaxStreamSubscribers overrides the stream subscriber cap; values
+// below 1 are ignored.
+func (h *MetricsHandler) SetMaxStreamSubscribers(n int) {
+	if n >= 1 {
+		h.streams.max = n
+	}
+}
+
+// StartHealthStream begins publishing the collector's health view to
+// stream subscribers at the given interval. No-op without a collector.
+func (h *MetricsHandler) StartHealthStream(interval time.Duration) {
+	if h.collector == nil {
+		return
+	}
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			event, err := json.Marshal(gin.H{"clusters": h.collector.Health()})
+			if err != nil {
+				log.Printf("marshaling health stream event: %v", err)
+				continue
+			}
+			h.streams.publish(event)
+		}
+	}()
+}
+
+// StreamHealth serves cluster health snapshots as server-sent events.
+// Connections past the subscriber cap are rejected with 503 so open
+// tabs cannot pile up into a resource problem for the monitor itself.
+func (h *MetricsHandler) StreamHealth(c *gin.Context) {
+	if h.collector == nil {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "collector disabled"})
+		return
+	}
+	ch, ok := h.streams.subscribe()
+	if !ok {
+		c.JSON(http.StatusServiceUnavailable, gin.H{"error": "stream subscriber limit reached"})
+		return
+	}
+	defer h.streams.unsubscribe(ch)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Writer.Flush()
+
+	done := c.Request.Context().Done()
+	for {
+		select {
+		case <-done:
+			return
+		case event := <-ch:
+			fmt.Fprintf(c.Writer, "data: %s\n\n", event)
+			c.Writer.Flush()
+		}
+	}
+}
@@ -0,0 +1,585 @@
+package models
+
+import (
+	"fmt"
+	"time"
+)
+
+// Severity is an ordered alert severity. The stored representation is
+// the display string, so pre-existing rows remain valid.
+type Severity string
+
+// Alert severities, ordered Info < Warning < Critical < Emergency.
+const (
+	SeverityInfo      Severity = "Info"
+	SeverityWarning   Severity = "Warning"
+	SeverityCritical  Severity = "Critical"
+	SeverityEmergency Severity = "Emergency"
+)
+
+// Rank orders severities for comparison; unrecognized values rank below
+// Info so they never trip minimum-severity filters.
+func (s Severity) Rank() int {
+	switch s {
+	case SeverityEmergency:
+		return 3
+	case SeverityCritical:
+		return 2
+	case SeverityWarning:
+		return 1
+	case SeverityInfo:
+		return 0
+	default:
+		return -1
+	}
+}
+
+// AtLeast reports whether s meets or exceeds min in the ordering.
+func (s Severity) AtLeast(min Severity) bool {
+	return s.Rank() >= min.Rank()
+}
+
+// Cluster statuses derived from collected metrics. StatusUnknown marks
+// a cluster not yet observed by any collection cycle.
+const (
+	StatusHealthy     = "Healthy"
+	StatusWarning     = "Warning"
+	StatusCritical    = "Critical"
+	StatusUnreachable = "Unreachable"
+	StatusUnknown     = "Unknown"
+	// StatusAuthError marks a cluster whose API server rejected our
+	// credentials — usually an expired exec-plugin token, not an
+	// outage, so it is kept distinct from Unreachable.
+	StatusAuthError = "AuthError"
+)
+
+// ClusterConfig describes a single monitored cluster as declared in
+// clusters.yaml.
+type ClusterConfig struct {
+	Name          string            `yaml:"name" json:"name"`
+	DisplayName   string            `yaml:"displayName" json:"displayName"`
+	Kubeconfig    string            `yaml:"kubeconfig" json:"kubeconfig"`
+	Context       string            `yaml:"context" json:"context"`
+	PrometheusURL string            `yaml:"prometheusUrl" json:"prometheusUrl"`
+	Labels        map[string]string `yaml:"labels" json:"labels,omitempty"`
+	Enabled       bool              `yaml:"enabled" json:"enabled"`
+	// PrometheusClusterLabel is a label matcher (e.g. cluster="prod-east")
+	// injected into the built-in cluster and node queries, so several
+	// clusters federating into one Prometheus each see their own subset
+	// instead of fleet-wide numbers. Custom Queries are used verbatim
+	// and must carry their own scoping.
+	PrometheusClusterLabel string `yaml:"prometheusClusterLabel" json:"prometheusClusterLabel,omitempty"`
+	// Namespaces restricts reads to the listed namespaces, for clusters
+	// monitored with a namespace-scoped service account. Cluster-wide
+	// listings iterate the set, and node-level calls the account cannot
+	// make degrade to empty results instead of errors.
+	Namespaces []string `yaml:"namespaces" json:"namespaces,omitempty"`
+	// UseInformers serves pod and node reads from a client-go informer
+	// cache instead of per-request List calls. Opt-in because the cache
+	// costs memory proportional to the cluster's object count.
+	UseInformers bool `yaml:"useInformers" json:"useInformers"`
+	// Queries substitutes custom PromQL expressions (typically cheap
+	// recording rules) for the built-in ones. Keys: cpu, memory, disk,
+	// node-cpu, node-memory, node-disk; node-level expressions may use
+	// a {node} placeholder. Unset keys keep the defaults.
+	Queries map[string]string `yaml:"queries" json:"queries,omitempty"`
+	// DefaultNamespaces scopes all-namespace pod, event, and summary
+	// queries to the listed namespaces by default. Unlike Namespaces it
+	// is a soft filter: a request naming a namespace (or "*" for
+	// everything) overrides it. Empty falls back to the global default.
+	DefaultNamespaces []string `yaml:"defaultNamespaces" json:"defaultNamespaces,omitempty"`
+	// PushToken authenticates snapshots pushed by an in-cluster agent
+	// for clusters the dashboard cannot poll. Empty disables the push
+	// endpoint for this cluster. Never serialized to JSON.
+	PushToken string `yaml:"pushToken" json:"-"`
+}
+
+// ClustersConfig is the top-level shape of clusters.yaml.
+type ClustersConfig struct {
+	Clusters []ClusterConfig `yaml:"clusters" json:"clusters"`
+	// DisabledAlertKinds lists alert kinds the collector must never
+	// raise, for deployments where another system already covers them.
+	DisabledAlertKinds []string `yaml:"disabledAlertKinds" json:"disabledAlertKinds,omitempty"`
+	// Discovery auto-generates cluster entries from the contexts of a
+	// kubeconfig, alongside any explicitly listed clusters. Explicit
+	// entries win on name collisions.
+	Discovery *DiscoveryConfig `yaml:"discovery" json:"discovery,omitempty"`
+	// DefaultNamespaces is the global default namespace filter applied
+	// to clusters that don't declare their own.
+	DefaultNamespaces []string `yaml:"defaultNamespaces" json:"defaultNamespaces,omitempty"`
+	// NodeRoleLabels lists extra label keys whose values count as node
+	// roles (e.g. cloud.google.com/gke-nodepool), for clusters that tag
+	// node pools with provider labels instead of the canonical
+	// node-role.kubernetes.io prefix. The standard prefix is always
+	// recognized too.
+	NodeRoleLabels []string `yaml:"nodeRoleLabels" json:"nodeRoleLabels,omitempty"`
+}
+
+// DiscoveryConfig drives kubeconfig-based cluster discovery, for setups
+// where the kubeconfig is already the source of truth and maintaining a
+// parallel cluster list by hand is busywork.
+type DiscoveryConfig struct {
+	// Kubeconfig is the file whose contexts are enumerated; empty uses
+	// the standard ~/.kube/config location.
+	Kubeconfig string `yaml:"kubeconfig" json:"kubeconfig"`
+	// ContextFilter is a regular expression limiting which contexts
+	// become clusters; empty admits every context.
+	ContextFilter string `yaml:"contextFilter" json:"contextFilter,omitempty"`
+	// PrometheusURLTemplate fills in each cluster's Prometheus endpoint
+	// with the {context} placeholder replaced by the context name;
+	// empty leaves discovered clusters without Prometheus metrics.
+	PrometheusURLTemplate string `yaml:"prometheusUrlTemplate" json:"prometheusUrlTemplate,omitempty"`
+	// Labels are applied to every discovered cluster.
+	Labels map[string]string `yaml:"labels" json:"labels,omitempty"`
+}
+
+// AlertThresholds holds the usage percentages at which the collector
+// raises warning and critical alerts.
+type AlertThresholds struct {
+	CPUWarning     float64 `json:"cpuWarning"`
+	CPUCritical    float64 `json:"cpuCritical"`
+	MemoryWarning  float64 `json:"memoryWarning"`
+	MemoryCritical float64 `json:"memoryCritical"`
+	DiskWarning    float64 `json:"diskWarning"`
+	DiskCritical   float64 `json:"diskCritical"`
+	// FailedPodsPercent and FailedPodsFloor make the failed-pod status
+	// rollup proportional to cluster size: Critical when failures reach
+	// the percentage of total pods, with the floor as an absolute
+	// minimum so small clusters still trip. Both zero restores the
+	// legacy any-failure-is-critical rule.
+	FailedPodsPercent float64 `json:"failedPodsPercent"`
+	FailedPodsFloor   int     `json:"failedPodsFloor"`
+	// OvercommitPercent warns when total pod requests exceed this share
+	// of allocatable capacity. A cluster at 30% utilization can still be
+	// 110% requested and unable to absorb a node loss; this catches
+	// that. Zero disables the check.
+	OvercommitPercent float64 `json:"overcommitPercent"`
+}
+
+// Validate rejects threshold sets that could never fire sensibly:
+// values outside (0, 100] or a warning at or above its critical.
+func (t AlertThresholds) Validate() error {
+	pairs := []struct {
+		name              string
+		warning, critical float64
+	}{
+		{"cpu", t.CPUWarning, t.CPUCritical},
+		{"memory", t.MemoryWarning, t.MemoryCritical},
+		{"disk", t.DiskWarning, t.DiskCritical},
+	}
+	for _, p := range pairs {
+		if p.warning <= 0 || p.critical > 100 {
+			return fmt.Errorf("%s thresholds must be within (0, 100]", p.name)
+		}
+		if p.warning >= p.critical {
+			return fmt.Errorf("%s warning threshold must be below critical", p.name)
+		}
+	}
+	if t.FailedPodsPercent < 0 || t.FailedPodsPercent > 100 {
+		return fmt.Errorf("failed-pod percentage must be within [0, 100]")
+	}
+	if t.FailedPodsFloor < 0 {
+		return fmt.Errorf("failed-pod floor must not be negative")
+	}
+	// Overcommit legitimately exceeds 100: requesting 120% of capacity
+	// is exactly the condition worth alerting on.
+	if t.OvercommitPercent < 0 {
+		return fmt.Errorf("overcommit percentage must not be negative")
+	}
+	return nil
+}
+
+// ClusterThresholds is a per-cluster override of the global alert
+// thresholds, persisted so edits made through the API survive restarts.
+type ClusterThresholds struct {
+	Cluster    string          `gorm:"primaryKey" json:"cluster"`
+	Thresholds AlertThresholds `gorm:"embedded" json:"thresholds"`
+}
+
+// Cluster is the API representation of a monitored cluster.
+type Cluster struct {
+	Name          string            `json:"name"`
+	DisplayName   string            `json:"displayName"`
+	Status        string            `json:"status"`
+	Reachable     bool              `json:"reachable"`
+	ServerVersion string            `json:"serverVersion,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	CPUUsage      float64           `json:"cpuUsage"`
+	// CPURequested is container CPU requests as a percentage of
+	// allocatable capacity — allocation pressure, as opposed to the raw
+	// utilization in CPUUsage.
+	CPURequested float64 `json:"cpuRequested,omitempty"`
+	MemoryUsage  float64 `json:"memoryUsage"`
+	// CPUMax and MemoryMax are the busiest single node's utilization,
+	// surfaced next to the cluster average so a pegged node isn't
+	// hidden by an otherwise idle fleet.
+	CPUMax      float64 `json:"cpuMax,omitempty"`
+	MemoryMax   float64 `json:"memoryMax,omitempty"`
+	DiskUsage   float64 `json:"diskUsage"`
+	NodeCount   int     `json:"nodeCount"`
+	PodCount    int     `json:"podCount"`
+	FailedPods  int     `json:"failedPods"`
+	PendingPods int     `json:"pendingPods"`
+	// PendingReasons counts pending pods by the reason they are stuck,
+	// so "N pending" is explainable from the summary alone.
+	PendingReasons map[string]int `json:"pendingReasons,omitempty"`
+	// ActiveAlerts counts this cluster's unresolved alerts;
+	// CriticalAlerts is the critical subset.
+	ActiveAlerts   int `json:"activeAlerts"`
+	CriticalAlerts int `json:"criticalAlerts"`
+	// ControlPlaneHealthy reports the API server's own health endpoints
+	// (/readyz, falling back to /healthz). On managed clusters where
+	// node-level data is restricted, this is often the only meaningful
+	// signal. Always false while the cluster is unreachable.
+	ControlPlaneHealthy bool `json:"controlPlaneHealthy"`
+	// APIServerLatencyP99 is the 99th-percentile apiserver request
+	// latency in seconds from Prometheus, when its metrics are scraped.
+	APIServerLatencyP99 float64 `json:"apiServerLatencyP99,omitempty"`
+	// MetricsError is set when Prometheus is configured but usage
+	// queries failed, so zeroed metrics are distinguishable from a
+	// genuinely idle cluster.
+	MetricsError string    `json:"metricsError,omitempty"`
+	LastUpdated  time.Time `json:"lastUpdated"`
+}
+
+// Node is the API representation of a cluster node.
+type Node struct {
+	Name        string   `json:"name"`
+	Status      string   `json:"status"`
+	Roles       []string `json:"roles"`
+	Schedulable bool     `json:"schedulable"`
+	CPUUsage    float64  `json:"cpuUsage"`
+	MemoryUsage float64  `json:"memoryUsage"`
+	// DiskUsage is the node's fullest filesystem in percent; NetworkRx
+	// and NetworkTx are throughput in bytes per second.
+	DiskUsage float64 `json:"diskUsage"`
+	NetworkRx float64 `json:"networkRx"`
+	NetworkTx float64 `json:"networkTx"`
+	// Capacity and allocatable from the node status; CPU in cores,
+	// memory in bytes.
+	CPUCapacity       float64 `json:"cpuCapacity"`
+	CPUAllocatable    float64 `json:"cpuAllocatable"`
+	MemoryCapacity    float64 `json:"memoryCapacity"`
+	MemoryAllocatable float64 `json:"memoryAllocatable"`
+	Version           string  `json:"version"`
+	Age               string  `json:"age"`
+}
+
+// Pod is the API representation of a pod. Cluster is populated only by
+// the fleet-wide listing, where the origin is otherwise ambiguous.
+type Pod struct {
+	Cluster   string `json:"cluster,omitempty"`
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Status    string `json:"status"`
+	// Reason explains why a Pending pod is stuck (e.g. Unschedulable,
+	// ImagePullBackOff); empty for other phases.
+	Reason   string `json:"reason,omitempty"`
+	Node     string `json:"node"`
+	Restarts int32  `json:"restarts"`
+	// RestartRate is the restart count over the recent rate window
+	// (see services.RestartRateWindow) — current churn, as opposed to
+	// the cumulative Restarts total since creation.
+	RestartRate float64 `json:"restartRate,omitempty"`
+	CPUUsage    float64 `json:"cpuUsage"`
+	MemoryUsage float64 `json:"memoryUsage"`
+	// Requests and limits are summed across containers; CPU in cores,
+	// memory in bytes. Zero means unset.
+	CPURequest    float64 `json:"cpuRequest"`
+	CPULimit      float64 `json:"cpuLimit"`
+	MemoryRequest float64 `json:"memoryRequest"`
+	MemoryLimit   float64 `json:"memoryLimit"`
+	// Usage as a percentage of the limit, present only when both the
+	// usage sample and the limit are known.
+	CPUUsageOfLimit    float64 `json:"cpuUsageOfLimit,omitempty"`
+	MemoryUsageOfLimit float64 `json:"memoryUsageOfLimit,omitempty"`
+	Age                string  `json:"age"`
+	// AgeSeconds is the same age as a sortable number; the display
+	// string ("3d") can't order 30s before 5m before 2h.
+	AgeSeconds int64 `json:"ageSeconds"`
+
+	// Containers breaks the aggregate Restarts down per container so
+	// the culprit in a multi-container pod is visible.
+	Containers []ContainerStatus `json:"containers,omitempty"`
+}
+
+// PodCondition is a condensed pod status condition.
+type PodCondition struct {
+	Type    string `json:"type"`
+	Status  string `json:"status"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// PodDetail is the full view of a single pod, extending the listing
+// shape with conditions, QoS class, and the pod's recent events.
+type PodDetail struct {
+	Pod
+	QOSClass   string         `json:"qosClass"`
+	Conditions []PodCondition `json:"conditions"`
+	Events     []Event        `json:"events"`
+	// CPUThrottlePercent is the share of CPU scheduling periods in
+	// which the pod was throttled; nil when the metric is absent
+	// (typically because no container sets a CPU limit).
+	CPUThrottlePercent *float64 `json:"cpuThrottlePercent,omitempty"`
+}
+
+// ContainerStatus is the per-container state of a pod.
+type ContainerStatus struct {
+	Name     string `json:"name"`
+	Image    string `json:"image"`
+	Ready    bool   `json:"ready"`
+	Restarts int32  `json:"restarts"`
+	State    string `json:"state"`
+	Reason   string `json:"reason,omitempty"`
+	// Message carries the kubelet's explanation for a waiting or
+	// terminated state, e.g. the registry error behind an
+	// ImagePullBackOff.
+	Message string `json:"message,omitempty"`
+}
+
+// PVC is the API representation of a persistent volume claim. Pending
+// claims usually indicate a storage provisioning failure.
+type PVC struct {
+	Name         string  `json:"name"`
+	Namespace    string  `json:"namespace"`
+	Status       string  `json:"status"`
+	Capacity     string  `json:"capacity"`
+	StorageClass string  `json:"storageClass"`
+	UsedPercent  float64 `json:"usedPercent,omitempty"`
+}
+
+// PodSummary aggregates pod phases for a cluster. PendingReasons counts
+// pending pods by the reason they are stuck.
+type PodSummary struct {
+	Total          int            `json:"total"`
+	Running        int            `json:"running"`
+	Pending        int            `json:"pending"`
+	Failed         int            `json:"failed"`
+	Succeeded      int            `json:"succeeded"`
+	PendingReasons map[string]int `json:"pendingReasons,omitempty"`
+}
+
+// Event is a condensed Kubernetes event.
+type Event struct {
+	Type     string    `json:"type"`
+	Reason   string    `json:"reason"`
+	Object   string    `json:"object"`
+	Message  string    `json:"message"`
+	Count    int32     `json:"count"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+// Service is the API representation of a Kubernetes service.
+// PendingExternalIP flags LoadBalancer services still waiting for the
+// cloud provider to assign an address.
+type Service struct {
+	Name              string   `json:"name"`
+	Namespace         string   `json:"namespace"`
+	Type              string   `json:"type"`
+	ClusterIP         string   `json:"clusterIP"`
+	ExternalIPs       []string `json:"externalIPs,omitempty"`
+	Ports             []string `json:"ports"`
+	PendingExternalIP bool     `json:"pendingExternalIP,omitempty"`
+	Age               string   `json:"age"`
+}
+
+// IngressBackend names a service and port an ingress routes to.
+type IngressBackend struct {
+	Service string `json:"service"`
+	Port    string `json:"port"`
+}
+
+// Ingress is the API representation of an ingress. Address carries the
+// load balancer address once one is assigned; empty means the ingress
+// is not (yet) externally reachable.
+type Ingress struct {
+	Name      string           `json:"name"`
+	Namespace string           `json:"namespace"`
+	Class     string           `json:"class,omitempty"`
+	Hosts     []string         `json:"hosts"`
+	Backends  []IngressBackend `json:"backends"`
+	Address   string           `json:"address,omitempty"`
+	Age       string           `json:"age"`
+}
+
+// Deployment summarizes a deployment's replica state.
+type Deployment struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Desired   int32  `json:"desired"`
+	Ready     int32  `json:"ready"`
+	Available int32  `json:"available"`
+	// Images are the pod template's container images, one per
+	// container, so environments can be checked for version skew.
+	Images []string `json:"images,omitempty"`
+	Age    string   `json:"age"`
+}
+
+// MetricSnapshot is a point-in-time record of cluster-level metrics
+// persisted by the collector.
+type MetricSnapshot struct {
+	ID          uint    `gorm:"primaryKey" json:"id"`
+	Cluster     string  `gorm:"index" json:"cluster"`
+	CPUUsage    float64 `json:"cpuUsage"`
+	MemoryUsage float64 `json:"memoryUsage"`
+	DiskUsage   float64 `json:"diskUsage"`
+	NodeCount   int     `json:"nodeCount"`
+	PodCount    int     `json:"podCount"`
+	FailedPods  int     `json:"failedPods"`
+	PendingPods int     `json:"pendingPods"`
+	// CPURequested and MemoryRequested are total pod requests as a
+	// percentage of allocatable capacity — how much of the cluster is
+	// promised away, regardless of actual utilization. Zero when
+	// kube-state-metrics is unavailable.
+	CPURequested    float64   `json:"cpuRequested"`
+	MemoryRequested float64   `json:"memoryRequested"`
+	Timestamp       time.Time `gorm:"index" json:"timestamp"`
+}
+
+// NodeSnapshot is a point-in-time record of one node's usage, persisted
+// by the collector alongside the cluster-level snapshot so individual
+// nodes can be charted over time.
+type NodeSnapshot struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Cluster     string    `gorm:"index" json:"cluster"`
+	Node        string    `gorm:"index" json:"node"`
+	CPUUsage    float64   `json:"cpuUsage"`
+	MemoryUsage float64   `json:"memoryUsage"`
+	Timestamp   time.Time `gorm:"index" json:"timestamp"`
+}
+
+// DaemonSet summarizes a daemon set's scheduling state. Degraded flags
+// ready falling short of desired, which often points at a broken node.
+type DaemonSet struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Desired   int32  `json:"desired"`
+	Ready     int32  `json:"ready"`
+	Available int32  `json:"available"`
+	Degraded  bool   `json:"degraded"`
+	Age       string `json:"age"`
+}
+
+// StatefulSet summarizes a stateful set's replica state. Degraded flags
+// ready falling short of desired.
+type StatefulSet struct {
+	Name      string `json:"name"`
+	Namespace string `json:"namespace"`
+	Desired   int32  `json:"desired"`
+	Ready     int32  `json:"ready"`
+	Available int32  `json:"available"`
+	Degraded  bool   `json:"degraded"`
+	Age       string `json:"age"`
+}
+
+// Job summarizes one batch job. Failing is set once the job has
+// recorded any failed pods, so a dashboard can surface batch problems
+// without inspecting pod lists.
+type Job struct {
+	Name        string     `json:"name"`
+	Namespace   string     `json:"namespace"`
+	Completions int32      `json:"completions"`
+	Succeeded   int32      `json:"succeeded"`
+	Failed      int32      `json:"failed"`
+	Active      int32      `json:"active"`
+	Failing     bool       `json:"failing"`
+	StartTime   *time.Time `json:"startTime,omitempty"`
+	Age         string     `json:"age"`
+}
+
+// CronJob summarizes one cron job. Overdue is set when the schedule
+// implies a run should have happened by now and none has, flagging
+// cron jobs that have silently stopped firing.
+type CronJob struct {
+	Name             string     `json:"name"`
+	Namespace        string     `json:"namespace"`
+	Schedule         string     `json:"schedule"`
+	Suspended        bool       `json:"suspended"`
+	Active           int        `json:"active"`
+	LastScheduleTime *time.Time `json:"lastScheduleTime,omitempty"`
+	Overdue          bool       `json:"overdue"`
+	Age              string     `json:"age"`
+}
+
+// MetricAnnotation marks a point on a cluster's metrics timeline, such
+// as a deployment pushed by CI/CD, so charts can show what happened
+// rather than just that something changed.
+type MetricAnnotation struct {
+	ID      uint   `gorm:"primaryKey" json:"id"`
+	Cluster string `gorm:"index" json:"cluster"`
+	Text    string `json:"text"`
+	// Type loosely categorizes the marker (e.g. deploy, incident,
+	// maintenance) for styling; free-form.
+	Type      string    `json:"type,omitempty"`
+	Timestamp time.Time `gorm:"index" json:"timestamp"`
+}
+
+// NotificationLog records one notification delivery attempt, so
+// whether an alert actually reached its channel is answerable from the
+// dashboard instead of from server logs.
+type NotificationLog struct {
+	ID      uint   `gorm:"primaryKey" json:"id"`
+	AlertID uint   `gorm:"index" json:"alertId"`
+	Cluster string `gorm:"index" json:"cluster"`
+	Channel string `json:"channel"`
+	// Resolve marks a resolution notice rather than an alert delivery.
+	Resolve   bool      `json:"resolve"`
+	Success   bool      `json:"success"`
+	Error     string    `json:"error,omitempty"`
+	Timestamp time.Time `gorm:"index" json:"timestamp"`
+}
+
+// StatusTransition records a cluster's derived status changing from one
+// collection cycle to the next, forming an availability timeline.
+type StatusTransition struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	Cluster    string    `gorm:"index" json:"cluster"`
+	FromStatus string    `json:"from"`
+	ToStatus   string    `json:"to"`
+	Timestamp  time.Time `gorm:"index" json:"timestamp"`
+}
+
+// Silence suppresses alerts matching its fields. Empty matcher fields
+// match anything, so a silence on cluster alone mutes every alert kind
+// there. A nil ExpiresAt means the silence stays until deleted.
+type Silence struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	Cluster   string     `json:"cluster,omitempty"`
+	Kind      string     `json:"kind,omitempty"`
+	Severity  Severity   `json:"severity,omitempty"`
+	Comment   string     `json:"comment,omitempty"`
+	ExpiresAt *time.Time `json:"expiresAt,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+}
+
+// Matches reports whether the silence applies to an alert with the
+// given cluster, kind, and severity.
+func (s Silence) Matches(cluster, kind string, severity Severity) bool {
+	if s.Cluster != "" && s.Cluster != cluster {
+		return false
+	}
+	if s.Kind != "" && s.Kind != kind {
+		return false
+	}
+	if s.Severity != "" && s.Severity != severity {
+		return false
+	}
+	return true
+}
+
+// Alert is a persisted alert raised by the collector. FirstSeen is set
+// when the condition is first observed and never changes; LastSeen is
+// bumped each time the condition recurs.
+type Alert struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	Cluster   string    `gorm:"index" json:"cluster"`
+	Kind      string    `gorm:"index" json:"kind"`
+	Severity  Severity  `json:"severity"`
+	Message   string    `json:"message"`
+	Count     int       `json:"count"`
+	Resolved  bool      `gorm:"index" json:"resolved"`
+	Timestamp time.Time `json:"timestamp"`
+	FirstSeen time.Time `json:"firstSeen"`
+	LastSeen  time.Time `json:"lastSeen"`
+}
@@ -0,0 +1,97 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rishabh998186/kdash-monitor/internal/models"
+)
+
+// discordMaxRetries bounds how many times a rate-limited post is
+// retried before giving up.
+const discordMaxRetries = 3
+
+// DiscordNotifier posts alerts to a Discord webhook as embeds.
+type DiscordNotifier struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewDiscordNotifier returns a DiscordNotifier for the given webhook
+// URL.
+func NewDiscordNotifier(webhookURL string) *DiscordNotifier {
+	return &DiscordNotifier{
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements Notifier.
+func (n *DiscordNotifier) Name() string { return "discord" }
+
+// Notify implements Notifier by posting an embed to the webhook,
+// backing off and retrying when Discord rate-limits the request.
+func (n *DiscordNotifier) Notify(ctx context.Context, alert models.Alert) error {
+	// Discord embed colors are decimal RGB.
+	color := 0xffae42 // orange
+	if alert.Severity == models.SeverityCritical {
+		color = 0xd00000 // red
+	}
+	payload := map[string]interface{}{
+		"embeds": []map[string]interface{}{{
+			"title": fmt.Sprintf("[%s] %s", alert.Severity, alert.Cluster),
+			"color": color,
+			"fields": []map[string]interface{}{
+				{"name": "Cluster", "value": alert.Cluster, "inline": true},
+				{"name": "Kind", "value": alert.Kind, "inline": true},
+				{"name": "Message", "value": alert.Message},
+			},
+			"timestamp": alert.Timestamp.Format(time.RFC3339),
+		}},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	for attempt := 0; ; attempt++ {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(body))
+		if err != nil {
+			return err
+		}
+		req.Header.Set("Content-Type", "application/json")
+		resp, err := n.client.Do(req)
+		if err != nil {
+			return fmt.Errorf("posting to discord: %w", err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode == http.StatusTooManyRequests && attempt < discordMaxRetries {
+			wait := retryAfter(resp, time.Second)
+			select {
+			case <-time.After(wait):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		if resp.StatusCode >= 300 {
+			return fmt.Errorf("discord webhook returned status %d", resp.StatusCode)
+		}
+		return nil
+	}
+}
+
+// retryAfter parses a Retry-After header (seconds), falling back to the
+// given default.
+func retryAfter(resp *http.Response, fallback time.Duration) time.Duration {
+	if v := resp.Header.Get("Retry-After"); v != "" {
+		if secs, err := strconv.ParseFloat(v, 64); err == nil && secs > 0 {
+			return time.Duration(secs * float64(time.Second))
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,162 @@
+package notifications
+
+import (
+	"context"
+	"crypto/tls"
+	"fmt"
+	"html"
+	"log"
+	"net"
+	"net/smtp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rishabh998186/kdash-monitor/internal/models"
+)
+
+// emailDigestDelay is how long the notifier collects alerts before
+// sending them as a single digest, so one bad collection cycle produces
+// one email instead of one per alert.
+const emailDigestDelay = 30 * time.Second
+
+// EmailConfig carries the SMTP settings for the email notifier.
+type EmailConfig struct {
+	Host     string
+	Port     string
+	Username string
+	Password string
+	From     string
+	To       []string
+	// ImplicitTLS dials a TLS connection directly (typically port 465);
+	// otherwise the notifier upgrades with STARTTLS when the server
+	// offers it.
+	ImplicitTLS bool
+}
+
+// EmailNotifier sends alert digests over SMTP.
+type EmailNotifier struct {
+	cfg EmailConfig
+
+	mu      sync.Mutex
+	pending []models.Alert
+	timer   *time.Timer
+}
+
+// NewEmailNotifier returns an EmailNotifier for the given SMTP
+// configuration.
+func NewEmailNotifier(cfg EmailConfig) *EmailNotifier {
+	if cfg.Port == "" {
+		cfg.Port = "587"
+	}
+	return &EmailNotifier{cfg: cfg}
+}
+
+// Name implements Notifier.
+func (n *EmailNotifier) Name() string { return "email" }
+
+// Notify implements Notifier by queueing the alert; alerts arriving
+// within the digest window are batched into one email.
+func (n *EmailNotifier) Notify(ctx context.Context, alert models.Alert) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	n.pending = append(n.pending, alert)
+	if n.timer == nil {
+		n.timer = time.AfterFunc(emailDigestDelay, n.flush)
+	}
+	return nil
+}
+
+// flush sends the queued alerts as one digest email.
+func (n *EmailNotifier) flush() {
+	n.mu.Lock()
+	alerts := n.pending
+	n.pending = nil
+	n.timer = nil
+	n.mu.Unlock()
+	if len(alerts) == 0 {
+		return
+	}
+	if err := n.send(alerts); err != nil {
+		log.Printf("email digest with %d alerts failed: %v", len(alerts), err)
+	}
+}
+
+// send delivers one digest email over SMTP.
+func (n *EmailNotifier) send(alerts []models.Alert) error {
+	addr := net.JoinHostPort(n.cfg.Host, n.cfg.Port)
+	var client *smtp.Client
+	var err error
+	if n.cfg.ImplicitTLS {
+		conn, dialErr := tls.Dial("tcp", addr, &tls.Config{ServerName: n.cfg.Host})
+		if dialErr != nil {
+			return fmt.Errorf("dialing smtp server: %w", dialErr)
+		}
+		client, err = smtp.NewClient(conn, n.cfg.Host)
+	} else {
+		client, err = smtp.Dial(addr)
+	}
+	if err != nil {
+		return fmt.Errorf("connecting to smtp server: %w", err)
+	}
+	defer client.Close()
+
+	if !n.cfg.ImplicitTLS {
+		if ok, _ := client.Extension("STARTTLS"); ok {
+			if err := client.StartTLS(&tls.Config{ServerName: n.cfg.Host}); err != nil {
+				return fmt.Errorf("starttls: %w", err)
+			}
+		}
+	}
+	if n.cfg.Username != "" {
+		auth := smtp.PlainAuth("", n.cfg.Username, n.cfg.Password, n.cfg.Host)
+		if err := client.Auth(auth); err != nil {
+			return fmt.Errorf("smtp auth: %w", err)
+		}
+	}
+	if err := client.Mail(n.cfg.From); err != nil {
+		return fmt.Errorf("smtp mail from: %w", err)
+	}
+	for _, to := range n.cfg.To {
+		if err := client.Rcpt(to); err != nil {
+			return fmt.Errorf("smtp rcpt %s: %w", to, err)
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return fmt.Errorf("smtp data: %w", err)
+	}
+	if _, err := w.Write(n.buildMessage(alerts)); err != nil {
+		return fmt.Errorf("writing message: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("finishing message: %w", err)
+	}
+	return client.Quit()
+}
+
+// buildMessage renders the digest as an HTML email.
+func (n *EmailNotifier) buildMessage(alerts []models.Alert) []byte {
+	subject := fmt.Sprintf("[kdash-monitor] %d alert(s)", len(alerts))
+	if len(alerts) == 1 {
+		subject = fmt.Sprintf("[kdash-monitor] %s: %s alert", alerts[0].Cluster, alerts[0].Severity)
+	}
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\r\n", n.cfg.From)
+	fmt.Fprintf(&b, "To: %s\r\n", strings.Join(n.cfg.To, ", "))
+	fmt.Fprintf(&b, "Subject: %s\r\n", subject)
+	b.WriteString("MIME-Version: 1.0\r\nContent-Type: text/html; charset=utf-8\r\n\r\n")
+	b.WriteString("<html><body><h2>kdash-monitor alerts</h2><table border=\"1\" cellpadding=\"6\" cellspacing=\"0\">")
+	b.WriteString("<tr><th>Cluster</th><th>Severity</th><th>Message</th><th>Time</th></tr>")
+	for _, alert := range alerts {
+		color := "#ffae42"
+		if alert.Severity == models.SeverityCritical {
+			color = "#d00000"
+		}
+		fmt.Fprintf(&b, "<tr><td>%s</td><td style=\"color:%s\">%s</td><td>%s</td><td>%s</td></tr>",
+			html.EscapeString(alert.Cluster), color, html.EscapeString(string(alert.Severity)),
+			html.EscapeString(alert.Message), alert.Timestamp.Format(time.RFC3339))
+	}
+	b.WriteString("</table></body></html>\r\n")
+	return []byte(b.String())
+}
@@ -0,0 +1,80 @@
+package notifications
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/rishabh998186/kdash-monitor/internal/models"
+)
+
+// Notifier delivers an alert to an external channel.
+type Notifier interface {
+	// Name identifies the channel in logs and the API.
+	Name() string
+	// Notify delivers a single alert.
+	Notify(ctx context.Context, alert models.Alert) error
+}
+
+// Resolver is implemented by notifiers that can also signal an alert's
+// resolution (e.g. closing a PagerDuty incident).
+type Resolver interface {
+	Resolve(ctx context.Context, alert models.Alert) error
+}
+
+// ShouldNotify reports whether an alert's severity meets the configured
+// minimum (NOTIFY_MIN_SEVERITY, default Warning).
+func ShouldNotify(alert models.Alert) bool {
+	min := models.Severity(os.Getenv("NOTIFY_MIN_SEVERITY"))
+	if min == "" {
+		min = models.SeverityWarning
+	}
+	return alert.Severity.AtLeast(min)
+}
+
+// FromEnv builds the notifiers enabled through environment variables.
+func FromEnv() []Notifier {
+	var notifiers []Notifier
+	if url := os.Getenv("SLACK_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, NewSlackNotifier(url))
+		log.Printf("slack notifications enabled")
+	}
+	if url := os.Getenv("WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, NewWebhookNotifier(url))
+		log.Printf("webhook notifications enabled")
+	}
+	if url := os.Getenv("DISCORD_WEBHOOK_URL"); url != "" {
+		notifiers = append(notifiers, NewDiscordNotifier(url))
+		log.Printf("discord notifications enabled")
+	}
+	if key := os.Getenv("PAGERDUTY_ROUTING_KEY"); key != "" {
+		notifiers = append(notifiers, NewPagerDutyNotifier(key, models.Severity(os.Getenv("PAGERDUTY_MIN_SEVERITY"))))
+		log.Printf("pagerduty notifications enabled")
+	}
+	if host, to := os.Getenv("SMTP_HOST"), os.Getenv("SMTP_TO"); host != "" && to != "" {
+		notifiers = append(notifiers, NewEmailNotifier(EmailConfig{
+			Host:        host,
+			Port:        os.Getenv("SMTP_PORT"),
+			Username:    os.Getenv("SMTP_USERNAME"),
+			Password:    os.Getenv("SMTP_PASSWORD"),
+			From:        os.Getenv("SMTP_FROM"),
+			To:          splitAddresses(to),
+			ImplicitTLS: os.Getenv("SMTP_TLS") == "true",
+		}))
+		log.Printf("email notifications enabled")
+	}
+	return notifiers
+}
+
+// splitAddresses parses a comma-separated address list, trimming
+// whitespace and dropping empty entries.
+func splitAddresses(raw string) []string {
+	var out []string
+	for _, addr := range strings.Split(raw, ",") {
+		if addr = strings.TrimSpace(addr); addr != "" {
+			out = append(out, addr)
+		}
+	}
+	return out
+}
@@ -0,0 +1,115 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rishabh998186/kdash-monitor/internal/models"
+)
+
+// pagerDutyEventsURL is the PagerDuty Events API v2 endpoint.
+const pagerDutyEventsURL = "https://events.pagerduty.com/v2/enqueue"
+
+// PagerDutyNotifier pages through the PagerDuty Events API v2. Repeated
+// alerts for the same (cluster, kind) share a dedup key so they update
+// one incident, and resolution sends a resolve event for that key.
+type PagerDutyNotifier struct {
+	routingKey  string
+	minSeverity models.Severity
+	client      *http.Client
+}
+
+// NewPagerDutyNotifier returns a PagerDutyNotifier using the given
+// integration routing key. minSeverity controls which alerts page;
+// empty defaults to Critical only.
+func NewPagerDutyNotifier(routingKey string, minSeverity models.Severity) *PagerDutyNotifier {
+	if minSeverity == "" {
+		minSeverity = models.SeverityCritical
+	}
+	return &PagerDutyNotifier{
+		routingKey:  routingKey,
+		minSeverity: minSeverity,
+		client:      &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements Notifier.
+func (n *PagerDutyNotifier) Name() string { return "pagerduty" }
+
+// dedupKey derives a stable incident key from the alert's cluster and
+// kind.
+func dedupKey(alert models.Alert) string {
+	return fmt.Sprintf("kdash-monitor/%s/%s", alert.Cluster, alert.Kind)
+}
+
+// Notify implements Notifier by triggering (or re-triggering) an
+// incident.
+func (n *PagerDutyNotifier) Notify(ctx context.Context, alert models.Alert) error {
+	if !alert.Severity.AtLeast(n.minSeverity) {
+		return nil
+	}
+	payload := map[string]interface{}{
+		"routing_key":  n.routingKey,
+		"event_action": "trigger",
+		"dedup_key":    dedupKey(alert),
+		"payload": map[string]interface{}{
+			"summary":   fmt.Sprintf("[%s] %s: %s", alert.Severity, alert.Cluster, alert.Message),
+			"source":    alert.Cluster,
+			"severity":  pagerDutySeverity(alert.Severity),
+			"timestamp": alert.Timestamp.Format(time.RFC3339),
+		},
+	}
+	return n.send(ctx, payload)
+}
+
+// Resolve sends a resolve event for the alert's incident.
+func (n *PagerDutyNotifier) Resolve(ctx context.Context, alert models.Alert) error {
+	if !alert.Severity.AtLeast(n.minSeverity) {
+		return nil
+	}
+	payload := map[string]interface{}{
+		"routing_key":  n.routingKey,
+		"event_action": "resolve",
+		"dedup_key":    dedupKey(alert),
+	}
+	return n.send(ctx, payload)
+}
+
+// send posts one event to the Events API.
+func (n *PagerDutyNotifier) send(ctx context.Context, payload map[string]interface{}) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, pagerDutyEventsURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting to pagerduty: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("pagerduty returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// pagerDutySeverity maps alert severities onto the Events API severity
+// values.
+func pagerDutySeverity(severity models.Severity) string {
+	switch severity {
+	case models.SeverityEmergency, models.SeverityCritical:
+		return "critical"
+	case models.SeverityWarning:
+		return "warning"
+	default:
+		return "info"
+	}
+}
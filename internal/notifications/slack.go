@@ -0,0 +1,64 @@
+package notifications
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rishabh998186/kdash-monitor/internal/models"
+)
+
+// SlackNotifier posts alerts to a Slack incoming webhook.
+type SlackNotifier struct {
+	webhookURL string
+	client     *http.Client
+}
+
+// NewSlackNotifier returns a SlackNotifier for the given webhook URL.
+func NewSlackNotifier(webhookURL string) *SlackNotifier {
+	return &SlackNotifier{
+		webhookURL: webhookURL,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// Name implements Notifier.
+func (n *SlackNotifier) Name() string { return "slack" }
+
+// Notify implements Notifier by posting a formatted message to the
+// webhook.
+func (n *SlackNotifier) Notify(ctx context.Context, alert models.Alert) error {
+	color := "#ffae42"
+	if alert.Severity == models.SeverityCritical {
+		color = "#d00000"
+	}
+	payload := map[string]interface{}{
+		"attachments": []map[string]interface{}{{
+			"color": color,
+			"title": fmt.Sprintf("[%s] %s", alert.Severity, alert.Cluster),
+			"text":  alert.Message,
+			"ts":    alert.Timestamp.Unix(),
+		}},
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.webhookURL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("posting to slack: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("slack webhook returned status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,1260 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+
+	"github.com/rishabh998186/kdash-monitor/internal/models"
+	"github.com/rishabh998186/kdash-monitor/internal/notifications"
+	"github.com/rishabh998186/kdash-monitor/internal/storage"
+)
+
+var (
+	collectionCycles = promauto.NewCounter(prometheus.CounterOpts{
+		Name: "kdash_collection_cycles_total",
+		Help: "Number of completed metric collection cycles.",
+	})
+	collectionErrors = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "kdash_collection_errors_total",
+		Help: "Number of per-cluster collection failures.",
+	}, []string{"cluster"})
+
+	// Collected cluster data re-exported as gauges, so an existing
+	// Prometheus/Grafana stack can scrape and graph it natively
+	// instead of going through kdash-monitor's own charts.
+	clusterCPUGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kdash_cluster_cpu_usage",
+		Help: "Cluster CPU utilization percentage from the last collection.",
+	}, []string{"cluster"})
+	clusterMemoryGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kdash_cluster_memory_usage",
+		Help: "Cluster memory utilization percentage from the last collection.",
+	}, []string{"cluster"})
+	clusterDiskGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kdash_cluster_disk_usage",
+		Help: "Fullest filesystem utilization percentage from the last collection.",
+	}, []string{"cluster"})
+	clusterNodeCountGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kdash_cluster_node_count",
+		Help: "Number of nodes seen in the last collection.",
+	}, []string{"cluster"})
+	clusterPodCountGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kdash_cluster_pod_count",
+		Help: "Number of pods counted in the last collection.",
+	}, []string{"cluster"})
+	clusterFailedPodsGauge = promauto.NewGaugeVec(prometheus.GaugeOpts{
+		Name: "kdash_cluster_failed_pods",
+		Help: "Number of failed pods counted in the last collection.",
+	}, []string{"cluster"})
+)
+
+// exportSnapshotGauges publishes a snapshot's figures on the /metrics
+// endpoint.
+func exportSnapshotGauges(snap *models.MetricSnapshot) {
+	labels := prometheus.Labels{"cluster": snap.Cluster}
+	clusterCPUGauge.With(labels).Set(snap.CPUUsage)
+	clusterMemoryGauge.With(labels).Set(snap.MemoryUsage)
+	clusterDiskGauge.With(labels).Set(snap.DiskUsage)
+	clusterNodeCountGauge.With(labels).Set(float64(snap.NodeCount))
+	clusterPodCountGauge.With(labels).Set(float64(snap.PodCount))
+	clusterFailedPodsGauge.With(labels).Set(float64(snap.FailedPods))
+}
+
+// knownAlertKinds is every alert kind the collector can raise; config
+// that disables anything else is a typo worth failing on.
+var knownAlertKinds = map[string]bool{
+	"cpu":               true,
+	"memory":            true,
+	"disk":              true,
+	"failed-pods":       true,
+	"pending-pods":      true,
+	"cpu-trend":         true,
+	"memory-trend":      true,
+	"node-notready":     true,
+	"auth-error":        true,
+	"crashloop":         true,
+	"sustained-cpu":     true,
+	"sustained-memory":  true,
+	"sustained-disk":    true,
+	"cpu-overcommit":    true,
+	"memory-overcommit": true,
+	"control-plane":     true,
+}
+
+// NotReadyNodes returns the names of nodes whose Ready condition is not
+// true.
+func NotReadyNodes(nodes []models.Node) []string {
+	var names []string
+	for _, n := range nodes {
+		if n.Status != "Ready" {
+			names = append(names, n.Name)
+		}
+	}
+	return names
+}
+
+// validateAlertKinds rejects alert kind names the collector does not
+// recognize.
+func validateAlertKinds(kinds []string) error {
+	for _, kind := range kinds {
+		if !knownAlertKinds[kind] {
+			return fmt.Errorf("unknown alert kind %q in disabledAlertKinds", kind)
+		}
+	}
+	return nil
+}
+
+// ClusterStatus records the outcome of the latest collection cycle for
+// one cluster.
+type ClusterStatus struct {
+	Cluster   string    `json:"cluster"`
+	Reachable bool      `json:"reachable"`
+	LastRun   time.Time `json:"lastRun"`
+	LastError string    `json:"lastError,omitempty"`
+}
+
+// MetricsCollector periodically gathers metrics for every enabled
+// cluster, persists snapshots, and raises alerts.
+type MetricsCollector struct {
+	k8s        *KubernetesService
+	prom       *PrometheusService
+	store      *storage.MetricsStore
+	notifiers  []notifications.Notifier
+	thresholds models.AlertThresholds
+	interval   time.Duration
+
+	mu         sync.RWMutex
+	status     map[string]ClusterStatus
+	lastStatus map[string]string
+	stopCh     chan struct{}
+
+	// ready flips once the first cluster completes a collection cycle;
+	// /readyz gates on it so fresh instances don't serve empty data.
+	ready bool
+
+	// escalation maps alert kinds to how long a warning may stay
+	// unresolved before it is promoted to critical.
+	escalation         map[string]time.Duration
+	renotifyOnEscalate bool
+
+	// disabledKinds holds alert kinds the config turned off entirely.
+	disabledKinds map[string]bool
+
+	// stagger spreads cluster collections across the interval instead
+	// of hitting every upstream at the same instant.
+	stagger bool
+
+	// silences caches the active silence rules, refreshed once per
+	// collection cycle so raiseAlert does not query per alert.
+	silences []models.Silence
+
+	// thresholdOverrides caches per-cluster threshold overrides from the
+	// store, refreshed each cycle so API edits apply without a restart.
+	thresholdOverrides map[string]models.AlertThresholds
+
+	// warmup suppresses alert creation for a cluster until it has been
+	// connected this long, so a freshly added or reconnected cluster
+	// doesn't fire a burst of spurious alerts while metrics settle.
+	warmup time.Duration
+
+	// connectedSince records when each cluster's API last became
+	// reachable after being down; guarded by mu.
+	connectedSince map[string]time.Time
+
+	// authFailures counts consecutive credential rejections per cluster,
+	// driving the client rebuild and auth alert; guarded by mu.
+	authFailures map[string]int
+
+	// notReadySince remembers when each NotReady node was first seen in
+	// that state, per cluster, so the alert can say how long it has been
+	// down; guarded by mu.
+	notReadySince map[string]map[string]time.Time
+
+	// deadband, when positive, skips persisting snapshots whose every
+	// metric is within that many percentage points of the last saved
+	// one (and whose counts are identical), shrinking the database for
+	// stable clusters. heartbeat bounds the gap: at least one snapshot
+	// per cluster is saved that often regardless. lastSaved tracks the
+	// most recent persisted snapshot per cluster; guarded by mu.
+	deadband  float64
+	heartbeat time.Duration
+	lastSaved map[string]*models.MetricSnapshot
+
+	// trendWindow and trendRate configure rate-of-change alerting: a
+	// warning fires when CPU or memory usage climbs more than trendRate
+	// percentage points across trendWindow. Zero window disables it.
+	trendWindow time.Duration
+	trendRate   float64
+
+	// pending holds writes that failed against the store, retried on
+	// the next cycle so transient storage blips don't lose data.
+	pendingMu        sync.Mutex
+	pendingSnapshots []*models.MetricSnapshot
+	pendingAlerts    []*models.Alert
+}
+
+// writeBufferCap bounds the number of failed writes retained per kind
+// so a long storage outage cannot grow the buffer without limit.
+const writeBufferCap = 1000
+
+// NewMetricsCollector wires a collector over the given services.
+func NewMetricsCollector(k8s *KubernetesService, prom *PrometheusService, store *storage.MetricsStore, notifiers []notifications.Notifier, thresholds models.AlertThresholds, interval time.Duration) *MetricsCollector {
+	return &MetricsCollector{
+		k8s:        k8s,
+		prom:       prom,
+		store:      store,
+		notifiers:  notifiers,
+		thresholds: thresholds,
+		interval:   interval,
+		stagger:    true,
+		warmup:     DefaultAlertWarmup,
+		status:     make(map[string]ClusterStatus),
+		lastStatus: make(map[string]string),
+		stopCh:     make(chan struct{}),
+	}
+}
+
+// DefaultAlertWarmup is how long after a cluster (re)connects before
+// its metrics may raise alerts, letting the first cycles settle.
+const DefaultAlertWarmup = 2 * time.Minute
+
+// SetWarmup overrides the alert warm-up window; zero disables it.
+func (c *MetricsCollector) SetWarmup(d time.Duration) {
+	c.warmup = d
+}
+
+// DefaultSnapshotHeartbeat is the longest a deadband may suppress
+// saves before a heartbeat snapshot is forced through.
+const DefaultSnapshotHeartbeat = 10 * time.Minute
+
+// SetDeadband enables only-changed snapshot persistence: saves are
+// skipped while every metric stays within delta percentage points of
+// the last saved snapshot, except that one snapshot per heartbeat is
+// always kept. Zero delta (the default) preserves the
+// save-every-cycle behavior; a non-positive heartbeat selects
+// DefaultSnapshotHeartbeat.
+func (c *MetricsCollector) SetDeadband(delta float64, heartbeat time.Duration) {
+	if delta <= 0 {
+		return
+	}
+	if heartbeat <= 0 {
+		heartbeat = DefaultSnapshotHeartbeat
+	}
+	c.deadband = delta
+	c.heartbeat = heartbeat
+}
+
+// SetEscalationPolicy configures per-kind durations after which an
+// unresolved warning is promoted to critical, and whether promotion
+// re-notifies.
+func (c *MetricsCollector) SetEscalationPolicy(policy map[string]time.Duration, renotify bool) {
+	c.escalation = policy
+	c.renotifyOnEscalate = renotify
+}
+
+// SetTrendPolicy enables rate-of-change alerting: usage climbing more
+// than rate percentage points within window raises a warning even while
+// the absolute value is still under the static thresholds.
+func (c *MetricsCollector) SetTrendPolicy(window time.Duration, rate float64) {
+	c.trendWindow = window
+	c.trendRate = rate
+}
+
+// SetDisabledKinds turns off raising the listed alert kinds entirely,
+// for deployments where another alerting system already covers them.
+func (c *MetricsCollector) SetDisabledKinds(kinds []string) {
+	c.disabledKinds = make(map[string]bool, len(kinds))
+	for _, kind := range kinds {
+		c.disabledKinds[kind] = true
+	}
+}
+
+// SetStagger toggles spreading cluster collections across the interval.
+// Disabling it restores simultaneous collection, which keeps snapshot
+// timestamps aligned at the cost of bursty upstream load.
+func (c *MetricsCollector) SetStagger(enabled bool) {
+	c.stagger = enabled
+}
+
+// Start launches the collection loop in a background goroutine.
+func (c *MetricsCollector) Start() {
+	go func() {
+		c.collectMetrics()
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				c.collectMetrics()
+			case <-c.stopCh:
+				return
+			}
+		}
+	}()
+	log.Printf("metrics collector started (interval %s)", c.interval)
+}
+
+// Stop terminates the collection loop.
+func (c *MetricsCollector) Stop() {
+	close(c.stopCh)
+}
+
+// ClusterHealth is the minimal per-cluster health view served to
+// status pages and uptime monitors.
+type ClusterHealth struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Reachable bool   `json:"reachable"`
+}
+
+// Health reports every enabled cluster's latest derived status from the
+// collector's own state, without touching any upstream. Clusters not
+// yet covered by a collection cycle report StatusUnknown.
+func (c *MetricsCollector) Health() []ClusterHealth {
+	configs := c.k8s.GetConfigs()
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]ClusterHealth, 0, len(configs))
+	for _, cfg := range configs {
+		if !cfg.Enabled {
+			continue
+		}
+		health := ClusterHealth{Name: cfg.Name, Status: models.StatusUnknown}
+		if st, ok := c.status[cfg.Name]; ok {
+			health.Reachable = st.Reachable
+		}
+		if last, ok := c.lastStatus[cfg.Name]; ok && last != "" {
+			health.Status = last
+		}
+		out = append(out, health)
+	}
+	return out
+}
+
+// Status returns the per-cluster outcome of the latest cycle.
+func (c *MetricsCollector) Status() []ClusterStatus {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]ClusterStatus, 0, len(c.status))
+	for _, st := range c.status {
+		out = append(out, st)
+	}
+	return out
+}
+
+// collectMetrics runs one collection cycle across all enabled clusters.
+// With staggering on, each cluster is assigned an evenly spaced offset
+// within the interval so upstream API servers and Prometheus endpoints
+// see a smooth load profile instead of a synchronized burst.
+func (c *MetricsCollector) collectMetrics() {
+	c.flushPending()
+	c.refreshSilences()
+	c.refreshThresholds()
+	var enabled []models.ClusterConfig
+	for _, cfg := range c.k8s.GetConfigs() {
+		if cfg.Enabled {
+			enabled = append(enabled, cfg)
+		}
+	}
+	var wg sync.WaitGroup
+	for i, cfg := range enabled {
+		wg.Add(1)
+		go func(offset time.Duration, cfg models.ClusterConfig) {
+			defer wg.Done()
+			if offset > 0 {
+				select {
+				case <-time.After(offset):
+				case <-c.stopCh:
+					return
+				}
+			}
+			c.collectOne(cfg)
+		}(c.clusterOffset(i, len(enabled)), cfg)
+	}
+	wg.Wait()
+	collectionCycles.Inc()
+}
+
+// clusterOffset returns how long cluster i of n waits into the cycle
+// before collecting; zero for every cluster when staggering is off.
+func (c *MetricsCollector) clusterOffset(i, n int) time.Duration {
+	if !c.stagger || n < 2 {
+		return 0
+	}
+	return c.interval * time.Duration(i) / time.Duration(n)
+}
+
+// collectOne collects a single cluster and records the outcome. The
+// timeout starts when collection actually begins, so a late slot in a
+// staggered cycle gets the same budget as the first.
+func (c *MetricsCollector) collectOne(cfg models.ClusterConfig) {
+	ctx, cancel := context.WithTimeout(context.Background(), c.interval)
+	defer cancel()
+	if err := c.collectCluster(ctx, cfg); err != nil {
+		collectionErrors.WithLabelValues(cfg.Name).Inc()
+		c.setStatus(ClusterStatus{Cluster: cfg.Name, Reachable: false, LastRun: time.Now(), LastError: err.Error()})
+		if errors.Is(err, ErrAuthRejected) {
+			c.handleAuthError(ctx, cfg.Name)
+		} else {
+			c.recordStatus(cfg.Name, models.StatusUnreachable)
+		}
+		log.Printf("collection failed for %s: %v", cfg.Name, err)
+		return
+	}
+	c.clearAuthError(ctx, cfg.Name)
+	c.setStatus(ClusterStatus{Cluster: cfg.Name, Reachable: true, LastRun: time.Now()})
+	c.markReady()
+}
+
+// handleAuthError records the distinct AuthError status, raises an
+// alert pointing at credentials rather than the network, and rebuilds
+// the client once the error recurs — short-lived exec-plugin tokens
+// usually come back on a fresh invocation.
+func (c *MetricsCollector) handleAuthError(ctx context.Context, cluster string) {
+	c.recordStatus(cluster, models.StatusAuthError)
+	c.raiseAlert(ctx, cluster, "auth-error", models.SeverityWarning,
+		"API server rejected credentials; refresh the kubeconfig token or check the exec plugin")
+	c.mu.Lock()
+	if c.authFailures == nil {
+		c.authFailures = make(map[string]int)
+	}
+	c.authFailures[cluster]++
+	recurring := c.authFailures[cluster] >= 2
+	c.mu.Unlock()
+	if recurring {
+		if err := c.k8s.RebuildClient(cluster); err != nil {
+			log.Printf("client rebuild after auth errors failed for %s: %v", cluster, err)
+			return
+		}
+		log.Printf("rebuilt client for %s after repeated auth errors", cluster)
+		c.mu.Lock()
+		c.authFailures[cluster] = 0
+		c.mu.Unlock()
+	}
+}
+
+// clearAuthError resolves a previous auth alert once collection works.
+func (c *MetricsCollector) clearAuthError(ctx context.Context, cluster string) {
+	c.mu.Lock()
+	had := c.authFailures[cluster] > 0
+	delete(c.authFailures, cluster)
+	c.mu.Unlock()
+	if had {
+		c.resolveAlert(ctx, cluster, "auth-error")
+	}
+}
+
+// markReady records that at least one cluster has completed a full
+// collection, flipping the readiness gate exactly once.
+func (c *MetricsCollector) markReady() {
+	c.mu.Lock()
+	c.ready = true
+	c.mu.Unlock()
+}
+
+// Ready reports whether at least one cluster has completed a collection
+// cycle, so /readyz can hold traffic off an instance that would still
+// render empty charts.
+func (c *MetricsCollector) Ready() bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.ready
+}
+
+// collectCluster gathers one cluster's snapshot and evaluates alerts.
+func (c *MetricsCollector) collectCluster(ctx context.Context, cfg models.ClusterConfig) error {
+	if err := c.k8s.CheckConnectivity(ctx, cfg.Name); err != nil {
+		c.markDisconnected(cfg.Name)
+		return err
+	}
+	c.markConnected(cfg.Name)
+	snap := &models.MetricSnapshot{
+		Cluster:   cfg.Name,
+		Timestamp: time.Now(),
+	}
+	var nodes []models.Node
+	if listed, err := c.k8s.GetNodes(ctx, cfg.Name, ""); err == nil {
+		nodes = listed
+		snap.NodeCount = len(nodes)
+	}
+	var pendingReasons map[string]int
+	if summary, err := c.k8s.GetPodSummary(ctx, cfg.Name); err == nil {
+		snap.PodCount = summary.Total
+		snap.FailedPods = summary.Failed
+		snap.PendingPods = summary.Pending
+		pendingReasons = summary.PendingReasons
+	}
+	if cfg.PrometheusURL != "" {
+		if cpu, err := c.prom.GetCPUUsage(ctx, cfg.PrometheusURL, cfg.PrometheusClusterLabel); err == nil {
+			snap.CPUUsage = cpu
+		}
+		if mem, err := c.prom.GetMemoryUsage(ctx, cfg.PrometheusURL, cfg.PrometheusClusterLabel); err == nil {
+			snap.MemoryUsage = mem
+		}
+		if disk, err := c.prom.GetDiskUsage(ctx, cfg.PrometheusURL, cfg.PrometheusClusterLabel); err == nil {
+			snap.DiskUsage = disk
+		}
+		// Request percentages need kube-state-metrics; absence simply
+		// leaves them zero, which also disables the overcommit alert.
+		if req, err := c.prom.GetCPURequestedPercent(ctx, cfg.PrometheusURL, cfg.PrometheusClusterLabel); err == nil {
+			snap.CPURequested = req
+		}
+		if req, err := c.prom.GetMemoryRequestedPercent(ctx, cfg.PrometheusURL, cfg.PrometheusClusterLabel); err == nil {
+			snap.MemoryRequested = req
+		}
+	}
+	// A snapshot inside the deadband skips only the database row;
+	// alerts, status, and gauges below still see the fresh values.
+	if !c.withinDeadband(snap) {
+		if err := c.store.SaveSnapshot(snap); err != nil {
+			log.Printf("failed to save snapshot for %s, buffering for retry: %v", cfg.Name, err)
+			c.bufferSnapshot(snap)
+		}
+		c.recordSaved(snap)
+	}
+	exportSnapshotGauges(snap)
+	c.collectNodeSnapshots(ctx, cfg, nodes, snap.Timestamp)
+	c.checkControlPlane(ctx, cfg.Name)
+	c.checkRestartRates(ctx, cfg)
+	c.checkAndCreateAlerts(ctx, cfg.Name, snap, pendingReasons, nodes)
+	c.recordStatus(cfg.Name, DetermineClusterStatus(c.thresholdsFor(cfg.Name), snap.CPUUsage, snap.MemoryUsage, snap.DiskUsage, snap.FailedPods, snap.PodCount, len(NotReadyNodes(nodes))))
+	return nil
+}
+
+// withinDeadband reports whether a snapshot is close enough to the
+// last saved one that persisting it would add storage without adding
+// information. History endpoints already plot by timestamp, so the
+// irregular intervals this produces render fine.
+func (c *MetricsCollector) withinDeadband(snap *models.MetricSnapshot) bool {
+	if c.deadband <= 0 {
+		return false
+	}
+	c.mu.RLock()
+	last := c.lastSaved[snap.Cluster]
+	c.mu.RUnlock()
+	if last == nil || snap.Timestamp.Sub(last.Timestamp) >= c.heartbeat {
+		return false
+	}
+	if snap.NodeCount != last.NodeCount || snap.PodCount != last.PodCount ||
+		snap.FailedPods != last.FailedPods || snap.PendingPods != last.PendingPods {
+		return false
+	}
+	deltas := []float64{
+		snap.CPUUsage - last.CPUUsage,
+		snap.MemoryUsage - last.MemoryUsage,
+		snap.DiskUsage - last.DiskUsage,
+		snap.CPURequested - last.CPURequested,
+		snap.MemoryRequested - last.MemoryRequested,
+	}
+	for _, d := range deltas {
+		if math.Abs(d) >= c.deadband {
+			return false
+		}
+	}
+	return true
+}
+
+// recordSaved notes the snapshot the deadband comparison runs against.
+func (c *MetricsCollector) recordSaved(snap *models.MetricSnapshot) {
+	if c.deadband <= 0 {
+		return
+	}
+	c.mu.Lock()
+	if c.lastSaved == nil {
+		c.lastSaved = make(map[string]*models.MetricSnapshot)
+	}
+	c.lastSaved[snap.Cluster] = snap
+	c.mu.Unlock()
+}
+
+// IngestSnapshot records an externally pushed snapshot as if a poll
+// cycle had produced it: persisted, evaluated against thresholds and
+// alert rules, and reflected in cluster status. This backs the push
+// endpoint for firewalled clusters the dashboard cannot reach.
+func (c *MetricsCollector) IngestSnapshot(ctx context.Context, cluster string, snap *models.MetricSnapshot) {
+	snap.Cluster = cluster
+	if snap.Timestamp.IsZero() {
+		snap.Timestamp = time.Now()
+	}
+	if err := c.store.SaveSnapshot(snap); err != nil {
+		log.Printf("failed to save pushed snapshot for %s, buffering for retry: %v", cluster, err)
+		c.bufferSnapshot(snap)
+	}
+	exportSnapshotGauges(snap)
+	c.checkAndCreateAlerts(ctx, cluster, snap, nil, nil)
+	c.recordStatus(cluster, DetermineClusterStatus(c.thresholdsFor(cluster), snap.CPUUsage, snap.MemoryUsage, snap.DiskUsage, snap.FailedPods, snap.PodCount, 0))
+	c.setStatus(ClusterStatus{Cluster: cluster, Reachable: true, LastRun: time.Now()})
+	c.markReady()
+}
+
+// collectNodeSnapshots persists one usage snapshot per node so node
+// detail views can chart a trend. Prometheus is the only usage source,
+// so clusters without one record nothing.
+func (c *MetricsCollector) collectNodeSnapshots(ctx context.Context, cfg models.ClusterConfig, nodes []models.Node, ts time.Time) {
+	if cfg.PrometheusURL == "" {
+		return
+	}
+	for _, node := range nodes {
+		snap := &models.NodeSnapshot{
+			Cluster:   cfg.Name,
+			Node:      node.Name,
+			Timestamp: ts,
+		}
+		if cpu, err := c.prom.GetNodeCPUUsage(ctx, cfg.PrometheusURL, node.Name, cfg.PrometheusClusterLabel); err == nil {
+			snap.CPUUsage = cpu
+		}
+		if mem, err := c.prom.GetNodeMemoryUsage(ctx, cfg.PrometheusURL, node.Name, cfg.PrometheusClusterLabel); err == nil {
+			snap.MemoryUsage = mem
+		}
+		if err := c.store.SaveNodeSnapshot(snap); err != nil {
+			log.Printf("failed to save node snapshot for %s/%s: %v", cfg.Name, node.Name, err)
+		}
+	}
+}
+
+// markConnected starts the warm-up clock the first cycle a cluster is
+// reachable; markDisconnected resets it so the grace period applies
+// again after a reconnect.
+func (c *MetricsCollector) markConnected(cluster string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connectedSince == nil {
+		c.connectedSince = make(map[string]time.Time)
+	}
+	if _, ok := c.connectedSince[cluster]; !ok {
+		c.connectedSince[cluster] = time.Now()
+	}
+}
+
+func (c *MetricsCollector) markDisconnected(cluster string) {
+	c.mu.Lock()
+	delete(c.connectedSince, cluster)
+	c.mu.Unlock()
+}
+
+// inWarmup reports whether a cluster is still inside the post-connect
+// grace period during which no alerts are created.
+func (c *MetricsCollector) inWarmup(cluster string) bool {
+	if c.warmup <= 0 {
+		return false
+	}
+	c.mu.RLock()
+	since, ok := c.connectedSince[cluster]
+	c.mu.RUnlock()
+	return ok && time.Since(since) < c.warmup
+}
+
+// restartRateThreshold is the restart count within the rate window at
+// which a pod counts as crash-looping.
+const restartRateThreshold = 3.0
+
+// checkRestartRates raises a crashloop alert for pods restarting
+// repeatedly right now, judged by the recent restart rate rather than
+// the cumulative counter — a pod that churned at startup but has been
+// stable since shouldn't alert forever.
+func (c *MetricsCollector) checkRestartRates(ctx context.Context, cfg models.ClusterConfig) {
+	if cfg.PrometheusURL == "" {
+		return
+	}
+	rates, err := c.prom.GetPodRestartRatesByPod(ctx, cfg.PrometheusURL, "", cfg.PrometheusClusterLabel)
+	if err != nil {
+		log.Printf("restart rate query failed for %s: %v", cfg.Name, err)
+		return
+	}
+	var crashing []string
+	// rates is keyed by "namespace/pod", so the alert names pods the
+	// operator can actually find.
+	for pod, rate := range rates {
+		if rate >= restartRateThreshold {
+			crashing = append(crashing, fmt.Sprintf("%s (%.0f)", pod, rate))
+		}
+	}
+	if len(crashing) == 0 {
+		c.resolveAlert(ctx, cfg.Name, "crashloop")
+		return
+	}
+	sort.Strings(crashing)
+	c.raiseAlert(ctx, cfg.Name, "crashloop", models.SeverityWarning, fmt.Sprintf(
+		"%d pod(s) restarting repeatedly in the last %s: %s",
+		len(crashing), RestartRateWindow, strings.Join(crashing, ", ")))
+}
+
+// checkControlPlane raises a critical alert when a reachable API
+// server reports itself unhealthy via /readyz — typically an etcd or
+// admission webhook problem that connectivity checks sail past. On
+// managed clusters with restricted node access this is often the only
+// control-plane signal available.
+func (c *MetricsCollector) checkControlPlane(ctx context.Context, cluster string) {
+	if c.inWarmup(cluster) {
+		return
+	}
+	if err := c.k8s.CheckControlPlane(ctx, cluster); err != nil {
+		c.raiseAlert(ctx, cluster, "control-plane", models.SeverityCritical,
+			fmt.Sprintf("API server reports unhealthy: %v", err))
+		return
+	}
+	c.resolveAlert(ctx, cluster, "control-plane")
+}
+
+// RestoreAlertState rebuilds the collector's in-memory alert lifecycle
+// state from the store so a restart continues where the previous
+// process left off instead of treating every cluster as brand new.
+// Clusters with alerts still active skip the connection warm-up — they
+// were evidently connected before the restart, and holding their alerts
+// frozen for the warm-up window would delay both updates and
+// auto-resolution. NotReady-node first-seen times are recovered from
+// the active node-notready alert so downtime durations survive the
+// restart rather than resetting to zero.
+func (c *MetricsCollector) RestoreAlertState() {
+	alerts, err := c.store.GetAlerts("", false, 0)
+	if err != nil {
+		log.Printf("failed to load active alerts on startup: %v", err)
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.connectedSince == nil {
+		c.connectedSince = make(map[string]time.Time)
+	}
+	if c.notReadySince == nil {
+		c.notReadySince = make(map[string]map[string]time.Time)
+	}
+	for _, alert := range alerts {
+		if _, ok := c.connectedSince[alert.Cluster]; !ok {
+			c.connectedSince[alert.Cluster] = time.Now().Add(-c.warmup)
+		}
+		if alert.Kind == "node-notready" {
+			c.notReadySince[alert.Cluster] = parseNotReadyMessage(alert.Message)
+		}
+	}
+	if len(alerts) > 0 {
+		log.Printf("restored alert state for %d active alert(s) from the store", len(alerts))
+	}
+}
+
+// parseNotReadyMessage recovers per-node first-seen times from a
+// node-notready alert message of the form produced by
+// checkNodeReadiness: "N NotReady node(s): a (since RFC3339); b (...)".
+// Entries that don't parse are dropped; checkNodeReadiness re-seeds
+// them with the current time on the next cycle.
+func parseNotReadyMessage(message string) map[string]time.Time {
+	seen := make(map[string]time.Time)
+	_, list, ok := strings.Cut(message, ": ")
+	if !ok {
+		return seen
+	}
+	for _, entry := range strings.Split(list, "; ") {
+		name, rest, ok := strings.Cut(entry, " (since ")
+		if !ok {
+			continue
+		}
+		since, err := time.Parse(time.RFC3339, strings.TrimSuffix(rest, ")"))
+		if err != nil {
+			continue
+		}
+		seen[name] = since
+	}
+	return seen
+}
+
+// recordStatus notes a cluster's derived status, persisting a
+// transition whenever it differs from the previous cycle's. The first
+// observation after startup sets the baseline without recording.
+func (c *MetricsCollector) recordStatus(cluster, status string) {
+	c.mu.Lock()
+	prev := c.lastStatus[cluster]
+	c.lastStatus[cluster] = status
+	c.mu.Unlock()
+	if prev == "" || prev == status {
+		return
+	}
+	t := &models.StatusTransition{
+		Cluster:    cluster,
+		FromStatus: prev,
+		ToStatus:   status,
+		Timestamp:  time.Now(),
+	}
+	if err := c.store.RecordTransition(t); err != nil {
+		log.Printf("failed to record status transition for %s: %v", cluster, err)
+	}
+}
+
+// checkAndCreateAlerts compares a snapshot against the thresholds,
+// raising, updating, or resolving alerts as needed.
+func (c *MetricsCollector) checkAndCreateAlerts(ctx context.Context, cluster string, snap *models.MetricSnapshot, pendingReasons map[string]int, nodes []models.Node) {
+	if c.inWarmup(cluster) {
+		return
+	}
+	thresholds := c.thresholdsFor(cluster)
+	c.evaluateAlert(ctx, cluster, "cpu", snap.CPUUsage, thresholds.CPUWarning, thresholds.CPUCritical,
+		fmt.Sprintf("CPU usage at %.1f%%", snap.CPUUsage))
+	c.evaluateAlert(ctx, cluster, "memory", snap.MemoryUsage, thresholds.MemoryWarning, thresholds.MemoryCritical,
+		fmt.Sprintf("Memory usage at %.1f%%", snap.MemoryUsage))
+	c.evaluateAlert(ctx, cluster, "disk", snap.DiskUsage, thresholds.DiskWarning, thresholds.DiskCritical,
+		fmt.Sprintf("Disk usage at %.1f%% on fullest filesystem", snap.DiskUsage))
+
+	failedMsg := fmt.Sprintf("%d failed pods", snap.FailedPods)
+	if snap.FailedPods > 0 {
+		c.raiseAlert(ctx, cluster, "failed-pods", models.SeverityWarning, failedMsg)
+	} else {
+		c.resolveAlert(ctx, cluster, "failed-pods")
+	}
+
+	if snap.PendingPods > 0 {
+		c.raiseAlert(ctx, cluster, "pending-pods", models.SeverityWarning,
+			formatPendingMessage(snap.PendingPods, pendingReasons))
+	} else {
+		c.resolveAlert(ctx, cluster, "pending-pods")
+	}
+
+	c.checkOvercommit(ctx, cluster, "cpu-overcommit", "CPU", snap.CPURequested, thresholds.OvercommitPercent)
+	c.checkOvercommit(ctx, cluster, "memory-overcommit", "Memory", snap.MemoryRequested, thresholds.OvercommitPercent)
+
+	c.checkNodeReadiness(ctx, cluster, nodes)
+
+	if c.trendWindow > 0 {
+		c.evaluateTrend(ctx, cluster, "cpu-trend", "CPU", snap.CPUUsage,
+			func(s models.MetricSnapshot) float64 { return s.CPUUsage })
+		c.evaluateTrend(ctx, cluster, "memory-trend", "Memory", snap.MemoryUsage,
+			func(s models.MetricSnapshot) float64 { return s.MemoryUsage })
+	}
+}
+
+// checkOvercommit warns when total pod requests exceed the configured
+// share of allocatable capacity. Overcommit is a leading indicator
+// utilization misses: a lightly loaded cluster that is 110% requested
+// has no headroom to reschedule pods when a node fails. A zero
+// threshold or a zero measurement (no kube-state-metrics) disables the
+// check.
+func (c *MetricsCollector) checkOvercommit(ctx context.Context, cluster, kind, label string, requested, threshold float64) {
+	if threshold <= 0 || requested <= 0 {
+		return
+	}
+	if requested > threshold {
+		c.raiseAlert(ctx, cluster, kind, models.SeverityWarning, fmt.Sprintf(
+			"%s requests at %.1f%% of allocatable capacity (threshold %.0f%%)", label, requested, threshold))
+		return
+	}
+	c.resolveAlert(ctx, cluster, kind)
+}
+
+// checkNodeReadiness raises one critical alert naming every NotReady
+// node and when it was first seen down, and resolves it once all nodes
+// are Ready again. A named node alert reaches the operator cycles
+// before the pod failures it causes would.
+func (c *MetricsCollector) checkNodeReadiness(ctx context.Context, cluster string, nodes []models.Node) {
+	notReady := NotReadyNodes(nodes)
+	c.mu.Lock()
+	if c.notReadySince == nil {
+		c.notReadySince = make(map[string]map[string]time.Time)
+	}
+	seen := c.notReadySince[cluster]
+	if seen == nil {
+		seen = make(map[string]time.Time)
+		c.notReadySince[cluster] = seen
+	}
+	current := make(map[string]bool, len(notReady))
+	parts := make([]string, 0, len(notReady))
+	for _, name := range notReady {
+		current[name] = true
+		if _, ok := seen[name]; !ok {
+			seen[name] = time.Now()
+		}
+		parts = append(parts, fmt.Sprintf("%s (since %s)", name, seen[name].Format(time.RFC3339)))
+	}
+	for name := range seen {
+		if !current[name] {
+			delete(seen, name)
+		}
+	}
+	c.mu.Unlock()
+
+	if len(notReady) == 0 {
+		c.resolveAlert(ctx, cluster, "node-notready")
+		return
+	}
+	c.raiseAlert(ctx, cluster, "node-notready", models.SeverityCritical,
+		fmt.Sprintf("%d NotReady node(s): %s", len(notReady), strings.Join(parts, "; ")))
+}
+
+// evaluateTrend raises a warning when a metric has climbed more than
+// trendRate percentage points across the trend window, and resolves it
+// once the climb flattens. Sparse history (less than half the window
+// covered) is ignored so a fresh cluster cannot false-fire.
+func (c *MetricsCollector) evaluateTrend(ctx context.Context, cluster, kind, label string, current float64, value func(models.MetricSnapshot) float64) {
+	since := time.Now().Add(-c.trendWindow)
+	snaps, err := c.store.GetSnapshots(cluster, since)
+	if err != nil || len(snaps) < 2 {
+		return
+	}
+	earliest := snaps[0]
+	if time.Since(earliest.Timestamp) < c.trendWindow/2 {
+		return
+	}
+	delta := current - value(earliest)
+	if delta >= c.trendRate {
+		c.raiseAlert(ctx, cluster, kind, models.SeverityWarning,
+			fmt.Sprintf("%s usage climbed %.1f points in %s (%.1f%% -> %.1f%%)",
+				label, delta, c.trendWindow, value(earliest), current))
+		return
+	}
+	c.resolveAlert(ctx, cluster, kind)
+}
+
+// pendingReasonsShown caps how many distinct reasons a pending-pods
+// alert message lists.
+const pendingReasonsShown = 3
+
+// formatPendingMessage summarizes pending pods with their most common
+// stuck reasons, so the alert says why and not just how many.
+func formatPendingMessage(count int, reasons map[string]int) string {
+	msg := fmt.Sprintf("%d pods pending", count)
+	if len(reasons) == 0 {
+		return msg
+	}
+	type reasonCount struct {
+		reason string
+		count  int
+	}
+	sorted := make([]reasonCount, 0, len(reasons))
+	for reason, n := range reasons {
+		sorted = append(sorted, reasonCount{reason, n})
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		if sorted[i].count != sorted[j].count {
+			return sorted[i].count > sorted[j].count
+		}
+		return sorted[i].reason < sorted[j].reason
+	})
+	if len(sorted) > pendingReasonsShown {
+		sorted = sorted[:pendingReasonsShown]
+	}
+	parts := make([]string, 0, len(sorted))
+	for _, rc := range sorted {
+		if rc.count > 1 {
+			parts = append(parts, fmt.Sprintf("%s x%d", rc.reason, rc.count))
+			continue
+		}
+		parts = append(parts, rc.reason)
+	}
+	return fmt.Sprintf("%s (%s)", msg, strings.Join(parts, "; "))
+}
+
+// evaluateAlert raises, escalates, or resolves a single threshold-based
+// alert kind.
+func (c *MetricsCollector) evaluateAlert(ctx context.Context, cluster, kind string, value, warning, critical float64, message string) {
+	switch {
+	case value >= critical:
+		c.raiseAlert(ctx, cluster, kind, models.SeverityCritical, message)
+	case value >= warning:
+		c.raiseAlert(ctx, cluster, kind, models.SeverityWarning, message)
+	default:
+		c.resolveAlert(ctx, cluster, kind)
+	}
+}
+
+// refreshSilences reloads the active silence rules for this cycle.
+func (c *MetricsCollector) refreshSilences() {
+	silences, err := c.store.GetActiveSilences(time.Now())
+	if err != nil {
+		log.Printf("failed to load silences, keeping previous set: %v", err)
+		return
+	}
+	c.mu.Lock()
+	c.silences = silences
+	c.mu.Unlock()
+}
+
+// refreshThresholds reloads the per-cluster threshold overrides.
+func (c *MetricsCollector) refreshThresholds() {
+	overrides, err := c.store.GetAllClusterThresholds()
+	if err != nil {
+		log.Printf("failed to load threshold overrides, keeping previous set: %v", err)
+		return
+	}
+	byCluster := make(map[string]models.AlertThresholds, len(overrides))
+	for _, o := range overrides {
+		byCluster[o.Cluster] = o.Thresholds
+	}
+	c.mu.Lock()
+	c.thresholdOverrides = byCluster
+	c.mu.Unlock()
+}
+
+// thresholdsFor returns a cluster's effective thresholds: its stored
+// override if one exists, the global defaults otherwise.
+func (c *MetricsCollector) thresholdsFor(cluster string) models.AlertThresholds {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	if t, ok := c.thresholdOverrides[cluster]; ok {
+		return t
+	}
+	return c.thresholds
+}
+
+// isSilenced reports whether an active silence rule matches the alert.
+func (c *MetricsCollector) isSilenced(cluster, kind string, severity models.Severity) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, s := range c.silences {
+		if s.Matches(cluster, kind, severity) {
+			return true
+		}
+	}
+	return false
+}
+
+// raiseAlert creates a new alert or bumps the occurrence count of an
+// existing active one, notifying on creation. Alerts matching an active
+// silence are not created; an alert raised before its silence keeps
+// updating so it resolves normally, but never re-notifies.
+func (c *MetricsCollector) raiseAlert(ctx context.Context, cluster, kind string, severity models.Severity, message string) {
+	if c.disabledKinds[kind] {
+		return
+	}
+	silenced := c.isSilenced(cluster, kind, severity)
+	existing, err := c.store.GetActiveAlert(cluster, kind)
+	if err != nil {
+		log.Printf("failed to look up active alert for %s/%s: %v", cluster, kind, err)
+		return
+	}
+	if existing != nil {
+		existing.Count++
+		// Never downgrade: an alert escalated by maybeEscalate keeps its
+		// promoted severity even though each cycle recomputes Warning.
+		if severity.Rank() > existing.Severity.Rank() {
+			existing.Severity = severity
+		}
+		existing.Message = message
+		existing.LastSeen = time.Now()
+		if err := c.store.UpdateAlert(existing); err != nil {
+			log.Printf("failed to update alert for %s/%s: %v", cluster, kind, err)
+		}
+		if !silenced {
+			c.maybeEscalate(ctx, existing)
+		}
+		return
+	}
+	if silenced {
+		return
+	}
+	now := time.Now()
+	alert := &models.Alert{
+		Cluster:   cluster,
+		Kind:      kind,
+		Severity:  severity,
+		Message:   message,
+		Count:     1,
+		Timestamp: now,
+		FirstSeen: now,
+		LastSeen:  now,
+	}
+	if err := c.store.SaveAlert(alert); err != nil {
+		log.Printf("failed to save alert for %s/%s, buffering for retry: %v", cluster, kind, err)
+		c.bufferAlert(alert)
+		return
+	}
+	c.notify(ctx, *alert)
+}
+
+// maybeEscalate promotes a long-running warning to critical once it has
+// been active past the configured duration for its kind. A persistent
+// problem shouldn't stay "just a warning" forever.
+func (c *MetricsCollector) maybeEscalate(ctx context.Context, alert *models.Alert) {
+	after, ok := c.escalation[alert.Kind]
+	if !ok || alert.Severity != models.SeverityWarning {
+		return
+	}
+	if time.Since(alert.FirstSeen) < after {
+		return
+	}
+	alert.Severity = models.SeverityCritical
+	if err := c.store.UpdateAlertSeverity(alert.ID, alert.Severity); err != nil {
+		log.Printf("failed to escalate alert for %s/%s: %v", alert.Cluster, alert.Kind, err)
+		return
+	}
+	log.Printf("escalated alert %s/%s to %s after %s unresolved",
+		alert.Cluster, alert.Kind, alert.Severity, after)
+	if c.renotifyOnEscalate {
+		c.notify(ctx, *alert)
+	}
+}
+
+// resolveAlert marks the active alert of a kind resolved, if present,
+// and tells resolution-capable notifiers.
+func (c *MetricsCollector) resolveAlert(ctx context.Context, cluster, kind string) {
+	existing, err := c.store.GetActiveAlert(cluster, kind)
+	if err != nil || existing == nil {
+		return
+	}
+	if err := c.store.ResolveAlert(existing.ID); err != nil {
+		log.Printf("failed to resolve alert for %s/%s: %v", cluster, kind, err)
+		return
+	}
+	for _, n := range c.notifiers {
+		if r, ok := n.(notifications.Resolver); ok {
+			err := r.Resolve(ctx, *existing)
+			if err != nil {
+				log.Printf("resolve notification via %s failed: %v", n.Name(), err)
+			}
+			c.logDelivery(*existing, n.Name(), true, err)
+		}
+	}
+}
+
+// notify fans an alert out to the configured notifiers.
+func (c *MetricsCollector) notify(ctx context.Context, alert models.Alert) {
+	if !notifications.ShouldNotify(alert) {
+		return
+	}
+	for _, n := range c.notifiers {
+		err := n.Notify(ctx, alert)
+		if err != nil {
+			log.Printf("notification via %s failed: %v", n.Name(), err)
+		}
+		c.logDelivery(alert, n.Name(), false, err)
+	}
+}
+
+// logDelivery records one delivery attempt in the notification audit
+// trail. Failing to write the record is logged but never blocks
+// alerting.
+func (c *MetricsCollector) logDelivery(alert models.Alert, channel string, resolve bool, deliveryErr error) {
+	entry := &models.NotificationLog{
+		AlertID:   alert.ID,
+		Cluster:   alert.Cluster,
+		Channel:   channel,
+		Resolve:   resolve,
+		Success:   deliveryErr == nil,
+		Timestamp: time.Now(),
+	}
+	if deliveryErr != nil {
+		entry.Error = deliveryErr.Error()
+	}
+	if err := c.store.SaveNotificationLog(entry); err != nil {
+		log.Printf("failed to record notification delivery: %v", err)
+	}
+}
+
+// bufferSnapshot queues a snapshot whose write failed, dropping the
+// oldest entry once the buffer is full.
+func (c *MetricsCollector) bufferSnapshot(snap *models.MetricSnapshot) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	if len(c.pendingSnapshots) >= writeBufferCap {
+		c.pendingSnapshots = c.pendingSnapshots[1:]
+	}
+	c.pendingSnapshots = append(c.pendingSnapshots, snap)
+}
+
+// bufferAlert queues an alert whose write failed, dropping the oldest
+// entry once the buffer is full.
+func (c *MetricsCollector) bufferAlert(alert *models.Alert) {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	if len(c.pendingAlerts) >= writeBufferCap {
+		c.pendingAlerts = c.pendingAlerts[1:]
+	}
+	c.pendingAlerts = append(c.pendingAlerts, alert)
+}
+
+// flushPending retries buffered writes, keeping whatever still fails
+// for the next cycle.
+func (c *MetricsCollector) flushPending() {
+	c.pendingMu.Lock()
+	defer c.pendingMu.Unlock()
+	if len(c.pendingSnapshots) == 0 && len(c.pendingAlerts) == 0 {
+		return
+	}
+	flushed := 0
+	remainingSnapshots := c.pendingSnapshots[:0]
+	for _, snap := range c.pendingSnapshots {
+		if err := c.store.SaveSnapshot(snap); err != nil {
+			remainingSnapshots = append(remainingSnapshots, snap)
+			continue
+		}
+		flushed++
+	}
+	c.pendingSnapshots = remainingSnapshots
+	remainingAlerts := c.pendingAlerts[:0]
+	for _, alert := range c.pendingAlerts {
+		if err := c.store.SaveAlert(alert); err != nil {
+			remainingAlerts = append(remainingAlerts, alert)
+			continue
+		}
+		flushed++
+	}
+	c.pendingAlerts = remainingAlerts
+	if flushed > 0 {
+		log.Printf("flushed %d buffered writes to the store (%d still pending)",
+			flushed, len(c.pendingSnapshots)+len(c.pendingAlerts))
+	}
+}
+
+func (c *MetricsCollector) setStatus(st ClusterStatus) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status[st.Cluster] = st
+}
+
+// DetermineClusterStatus rolls metrics up into a single cluster status.
+// Any NotReady node makes the cluster critical. Failed pods count
+// proportionally to cluster size — one failed pod out of thousands
+// shouldn't flip a large cluster red — with an absolute floor so small
+// clusters still trip. With an unknown total or unconfigured failed-pod
+// thresholds, any failure is critical, as before.
+func DetermineClusterStatus(thresholds models.AlertThresholds, cpu, memory, disk float64, failedPods, totalPods, notReadyNodes int) string {
+	failedCritical := failedPods > 0
+	if totalPods > 0 && (thresholds.FailedPodsPercent > 0 || thresholds.FailedPodsFloor > 0) {
+		failedCritical = failedPods >= failedPodsCriticalAt(thresholds, totalPods)
+	}
+	switch {
+	case cpu >= thresholds.CPUCritical || memory >= thresholds.MemoryCritical ||
+		disk >= thresholds.DiskCritical || failedCritical || notReadyNodes > 0:
+		return models.StatusCritical
+	case cpu >= thresholds.CPUWarning || memory >= thresholds.MemoryWarning ||
+		disk >= thresholds.DiskWarning:
+		return models.StatusWarning
+	default:
+		return models.StatusHealthy
+	}
+}
+
+// failedPodsCriticalAt is the failed-pod count at which a cluster of
+// the given size rolls up Critical: the configured percentage of total
+// pods, but never below the floor, and at least one.
+func failedPodsCriticalAt(t models.AlertThresholds, totalPods int) int {
+	threshold := int(math.Ceil(float64(totalPods) * t.FailedPodsPercent / 100))
+	if threshold < t.FailedPodsFloor {
+		threshold = t.FailedPodsFloor
+	}
+	if threshold < 1 {
+		threshold = 1
+	}
+	return threshold
+}
+
+// DefaultThresholds returns the built-in alerting thresholds.
+func DefaultThresholds() models.AlertThresholds {
+	return models.AlertThresholds{
+		CPUWarning:        80,
+		CPUCritical:       90,
+		MemoryWarning:     85,
+		MemoryCritical:    95,
+		DiskWarning:       85,
+		DiskCritical:      95,
+		FailedPodsPercent: 5,
+		FailedPodsFloor:   3,
+		OvercommitPercent: 110,
+	}
+}
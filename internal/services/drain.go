@@ -0,0 +1,226 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DefaultDrainTimeout bounds a drain when no override is given.
+const DefaultDrainTimeout = 5 * time.Minute
+
+// drainRetryInterval is how long a drain waits between eviction passes
+// while PodDisruptionBudgets block remaining pods.
+const drainRetryInterval = 5 * time.Second
+
+// DrainOptions configures a node drain.
+type DrainOptions struct {
+	// GracePeriodSeconds overrides each pod's termination grace period
+	// when non-nil.
+	GracePeriodSeconds *int64
+	// Timeout bounds the whole drain; zero selects DefaultDrainTimeout.
+	Timeout time.Duration
+}
+
+// DrainStatus reports the progress of an in-flight or finished drain.
+type DrainStatus struct {
+	Cluster    string     `json:"cluster"`
+	Node       string     `json:"node"`
+	State      string     `json:"state"` // draining, done, failed
+	Evicted    int        `json:"evicted"`
+	Skipped    int        `json:"skipped"`
+	Pending    int        `json:"pending"`
+	Errors     []string   `json:"errors,omitempty"`
+	StartedAt  time.Time  `json:"startedAt"`
+	FinishedAt *time.Time `json:"finishedAt,omitempty"`
+}
+
+// drainKey identifies a drain by cluster and node.
+func drainKey(cluster, node string) string {
+	return cluster + "/" + node
+}
+
+// StartDrain cordons a node and begins evicting its pods in the
+// background. It returns an error if a drain for the node is already
+// running; progress is reported through DrainStatuses.
+func (s *KubernetesService) StartDrain(cluster, node string, opts DrainOptions) error {
+	if _, err := s.client(cluster); err != nil {
+		return err
+	}
+	key := drainKey(cluster, node)
+
+	s.drainMu.Lock()
+	if st, ok := s.drains[key]; ok && st.State == "draining" {
+		s.drainMu.Unlock()
+		return fmt.Errorf("drain already in progress for node %s on %s", node, cluster)
+	}
+	status := &DrainStatus{
+		Cluster:   cluster,
+		Node:      node,
+		State:     "draining",
+		StartedAt: time.Now(),
+	}
+	s.drains[key] = status
+	s.drainMu.Unlock()
+
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = DefaultDrainTimeout
+	}
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		s.runDrain(ctx, status, opts)
+	}()
+	return nil
+}
+
+// DrainStatuses returns the status of every drain started since the
+// process came up, running or finished.
+func (s *KubernetesService) DrainStatuses() []DrainStatus {
+	s.drainMu.Lock()
+	defer s.drainMu.Unlock()
+	out := make([]DrainStatus, 0, len(s.drains))
+	for _, st := range s.drains {
+		out = append(out, *st)
+	}
+	return out
+}
+
+// updateDrain applies a mutation to a drain's status under the lock.
+func (s *KubernetesService) updateDrain(status *DrainStatus, fn func(*DrainStatus)) {
+	s.drainMu.Lock()
+	fn(status)
+	s.drainMu.Unlock()
+}
+
+// finishDrain records a drain's terminal state.
+func (s *KubernetesService) finishDrain(status *DrainStatus, state string) {
+	now := time.Now()
+	s.updateDrain(status, func(st *DrainStatus) {
+		st.State = state
+		st.FinishedAt = &now
+	})
+}
+
+// runDrain cordons the node and evicts its pods, retrying pods blocked
+// by PodDisruptionBudgets until the context expires. DaemonSet-managed
+// and mirror pods are skipped, as are pods that already finished.
+func (s *KubernetesService) runDrain(ctx context.Context, status *DrainStatus, opts DrainOptions) {
+	cluster, node := status.Cluster, status.Node
+	if err := s.CordonNode(ctx, cluster, node); err != nil {
+		s.updateDrain(status, func(st *DrainStatus) {
+			st.Errors = append(st.Errors, fmt.Sprintf("cordon failed: %v", err))
+		})
+		s.finishDrain(status, "failed")
+		return
+	}
+
+	client, err := s.client(cluster)
+	if err != nil {
+		s.updateDrain(status, func(st *DrainStatus) {
+			st.Errors = append(st.Errors, err.Error())
+		})
+		s.finishDrain(status, "failed")
+		return
+	}
+	list, err := client.CoreV1().Pods(metav1.NamespaceAll).List(ctx, metav1.ListOptions{
+		FieldSelector: "spec.nodeName=" + node,
+	})
+	if err != nil {
+		s.updateDrain(status, func(st *DrainStatus) {
+			st.Errors = append(st.Errors, fmt.Sprintf("listing pods: %v", err))
+		})
+		s.finishDrain(status, "failed")
+		return
+	}
+
+	type target struct{ namespace, name string }
+	var pending []target
+	skipped := 0
+	for _, p := range list.Items {
+		if isDaemonSetPod(p.OwnerReferences) || isMirrorPod(p.Annotations) ||
+			p.Status.Phase == "Succeeded" || p.Status.Phase == "Failed" {
+			skipped++
+			continue
+		}
+		pending = append(pending, target{p.Namespace, p.Name})
+	}
+	s.updateDrain(status, func(st *DrainStatus) {
+		st.Skipped = skipped
+		st.Pending = len(pending)
+	})
+
+	for len(pending) > 0 {
+		var blocked []target
+		for _, t := range pending {
+			eviction := &policyv1.Eviction{
+				ObjectMeta:    metav1.ObjectMeta{Name: t.name, Namespace: t.namespace},
+				DeleteOptions: &metav1.DeleteOptions{GracePeriodSeconds: opts.GracePeriodSeconds},
+			}
+			err := client.CoreV1().Pods(t.namespace).EvictV1(ctx, eviction)
+			switch {
+			case err == nil, apierrors.IsNotFound(err):
+				s.updateDrain(status, func(st *DrainStatus) {
+					st.Evicted++
+					st.Pending--
+				})
+			case apierrors.IsTooManyRequests(err):
+				// A PodDisruptionBudget is blocking this eviction;
+				// retry on the next pass.
+				blocked = append(blocked, t)
+			default:
+				s.updateDrain(status, func(st *DrainStatus) {
+					st.Errors = append(st.Errors, fmt.Sprintf("evicting %s/%s: %v", t.namespace, t.name, err))
+					st.Pending--
+				})
+			}
+		}
+		pending = blocked
+		if len(pending) == 0 {
+			break
+		}
+		select {
+		case <-time.After(drainRetryInterval):
+		case <-ctx.Done():
+			s.updateDrain(status, func(st *DrainStatus) {
+				st.Errors = append(st.Errors, fmt.Sprintf("timed out with %d pods still blocked by disruption budgets", len(pending)))
+			})
+			s.finishDrain(status, "failed")
+			return
+		}
+	}
+
+	now := time.Now()
+	var evicted, skippedCount int
+	s.updateDrain(status, func(st *DrainStatus) {
+		st.State = "done"
+		if len(st.Errors) > 0 {
+			st.State = "failed"
+		}
+		st.FinishedAt = &now
+		evicted, skippedCount = st.Evicted, st.Skipped
+	})
+	log.Printf("drain of node %s on %s finished: %d evicted, %d skipped", node, cluster, evicted, skippedCount)
+}
+
+// isDaemonSetPod reports whether a pod is managed by a DaemonSet.
+func isDaemonSetPod(owners []metav1.OwnerReference) bool {
+	for _, ref := range owners {
+		if ref.Kind == "DaemonSet" {
+			return true
+		}
+	}
+	return false
+}
+
+// isMirrorPod reports whether a pod is a static pod's mirror.
+func isMirrorPod(annotations map[string]string) bool {
+	_, ok := annotations["kubernetes.io/config.mirror"]
+	return ok
+}
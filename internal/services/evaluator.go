@@ -0,0 +1,154 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/rishabh998186/kdash-monitor/internal/models"
+	"github.com/rishabh998186/kdash-monitor/internal/storage"
+)
+
+// DefaultEvalInterval is how often sustained-condition rules are
+// re-evaluated when no override is configured.
+const DefaultEvalInterval = time.Minute
+
+// EvalRule describes one sustained-condition alert: the metric must
+// stay at or above Threshold across every stored snapshot in the For
+// window before the alert fires, so a momentary spike caught by a
+// single snapshot never alerts on its own.
+type EvalRule struct {
+	Metric    string // cpu, memory, or disk
+	Threshold float64
+	For       time.Duration
+	Severity  models.Severity
+}
+
+// Kind returns the alert kind the rule raises.
+func (r EvalRule) Kind() string {
+	return "sustained-" + r.Metric
+}
+
+// Validate rejects rules that could never fire sensibly.
+func (r EvalRule) Validate() error {
+	switch r.Metric {
+	case "cpu", "memory", "disk":
+	default:
+		return fmt.Errorf("unknown metric %q in alert rule", r.Metric)
+	}
+	if r.Threshold <= 0 || r.Threshold > 100 {
+		return fmt.Errorf("alert rule threshold must be within (0, 100]")
+	}
+	if r.For <= 0 {
+		return fmt.Errorf("alert rule duration must be positive")
+	}
+	if r.Severity.Rank() < 0 {
+		return fmt.Errorf("unknown severity %q in alert rule", r.Severity)
+	}
+	return nil
+}
+
+// AlertEvaluator applies sustained-condition rules over the stored
+// snapshot history on its own schedule, decoupled from collection.
+// Alerts are raised and resolved through the collector so silences,
+// disabled kinds, and escalation all apply uniformly.
+type AlertEvaluator struct {
+	collector *MetricsCollector
+	store     *storage.MetricsStore
+	rules     []EvalRule
+	interval  time.Duration
+	stopCh    chan struct{}
+}
+
+// NewAlertEvaluator wires an evaluator over the collector's alert
+// machinery. A non-positive interval selects the default.
+func NewAlertEvaluator(collector *MetricsCollector, store *storage.MetricsStore, rules []EvalRule, interval time.Duration) *AlertEvaluator {
+	if interval <= 0 {
+		interval = DefaultEvalInterval
+	}
+	return &AlertEvaluator{
+		collector: collector,
+		store:     store,
+		rules:     rules,
+		interval:  interval,
+		stopCh:    make(chan struct{}),
+	}
+}
+
+// Start launches the evaluation loop in a background goroutine.
+func (e *AlertEvaluator) Start() {
+	go func() {
+		ticker := time.NewTicker(e.interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				e.evaluate()
+			case <-e.stopCh:
+				return
+			}
+		}
+	}()
+	log.Printf("alert evaluator started (%d rules, interval %s)", len(e.rules), e.interval)
+}
+
+// Stop terminates the evaluation loop.
+func (e *AlertEvaluator) Stop() {
+	close(e.stopCh)
+}
+
+// evaluate runs every rule against every enabled cluster.
+func (e *AlertEvaluator) evaluate() {
+	ctx, cancel := context.WithTimeout(context.Background(), e.interval)
+	defer cancel()
+	for _, cfg := range e.collector.k8s.GetConfigs() {
+		if !cfg.Enabled {
+			continue
+		}
+		for _, rule := range e.rules {
+			e.evaluateRule(ctx, cfg.Name, rule)
+		}
+	}
+}
+
+// evaluateRule checks one rule against one cluster's recent snapshots,
+// raising when the condition held across the whole window and resolving
+// once any snapshot dips below the threshold. Windows not yet covered
+// by history are left alone rather than guessed at.
+func (e *AlertEvaluator) evaluateRule(ctx context.Context, cluster string, rule EvalRule) {
+	start := time.Now().Add(-rule.For)
+	snaps, err := e.store.GetSnapshots(cluster, start)
+	if err != nil {
+		log.Printf("alert evaluation failed for %s: %v", cluster, err)
+		return
+	}
+	// The window must actually be covered: at least two snapshots with
+	// the oldest near the window start, otherwise a single high sample
+	// right after startup would fire immediately.
+	if len(snaps) < 2 || snaps[0].Timestamp.Sub(start) > e.collector.interval {
+		return
+	}
+	var last float64
+	for _, snap := range snaps {
+		last = snapshotMetric(snap, rule.Metric)
+		if last < rule.Threshold {
+			e.collector.resolveAlert(ctx, cluster, rule.Kind())
+			return
+		}
+	}
+	e.collector.raiseAlert(ctx, cluster, rule.Kind(), rule.Severity,
+		fmt.Sprintf("%s usage at or above %.1f%% for %s (now %.1f%%)", rule.Metric, rule.Threshold, rule.For, last))
+}
+
+// snapshotMetric picks a rule's metric out of a snapshot.
+func snapshotMetric(snap models.MetricSnapshot, metric string) float64 {
+	switch metric {
+	case "cpu":
+		return snap.CPUUsage
+	case "memory":
+		return snap.MemoryUsage
+	default:
+		return snap.DiskUsage
+	}
+}
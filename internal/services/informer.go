@@ -0,0 +1,120 @@
+package services
+
+import (
+	"log"
+	"sort"
+
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corev1listers "k8s.io/client-go/listers/core/v1"
+	"k8s.io/client-go/tools/cache"
+
+	"github.com/rishabh998186/kdash-monitor/internal/models"
+)
+
+// clusterInformer holds a cluster's shared informer factory and the
+// listers read by the cached lookup paths.
+type clusterInformer struct {
+	factory    informers.SharedInformerFactory
+	podLister  corev1listers.PodLister
+	nodeLister corev1listers.NodeLister
+	podSynced  cache.InformerSynced
+	nodeSynced cache.InformerSynced
+	stopCh     chan struct{}
+}
+
+// startInformer launches a pod and node informer for a cluster. Reads
+// fall back to direct List calls until the caches sync.
+func (s *KubernetesService) startInformer(cluster string, client kubernetes.Interface) {
+	factory := informers.NewSharedInformerFactory(client, 0)
+	pods := factory.Core().V1().Pods()
+	nodes := factory.Core().V1().Nodes()
+	inf := &clusterInformer{
+		factory:    factory,
+		podLister:  pods.Lister(),
+		nodeLister: nodes.Lister(),
+		podSynced:  pods.Informer().HasSynced,
+		nodeSynced: nodes.Informer().HasSynced,
+		stopCh:     make(chan struct{}),
+	}
+	s.informers[cluster] = inf
+	factory.Start(inf.stopCh)
+	go func() {
+		if cache.WaitForCacheSync(inf.stopCh, inf.podSynced, inf.nodeSynced) {
+			log.Printf("informer cache synced for cluster %s", cluster)
+		}
+	}()
+}
+
+// stopInformer shuts a cluster's informer down, e.g. when the cluster
+// is removed on reload.
+func (s *KubernetesService) stopInformer(cluster string) {
+	if inf, ok := s.informers[cluster]; ok {
+		close(inf.stopCh)
+		delete(s.informers, cluster)
+	}
+}
+
+// informer returns a cluster's informer once its caches have synced.
+func (s *KubernetesService) informer(cluster string) (*clusterInformer, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	inf, ok := s.informers[cluster]
+	if !ok || !inf.podSynced() || !inf.nodeSynced() {
+		return nil, false
+	}
+	return inf, true
+}
+
+// cachedPods serves a pod listing from the informer cache, reporting
+// false when the cluster has no synced informer.
+func (s *KubernetesService) cachedPods(cluster, namespace string) ([]models.Pod, bool) {
+	inf, ok := s.informer(cluster)
+	if !ok {
+		return nil, false
+	}
+	list, err := inf.podLister.Pods(namespace).List(labels.Everything())
+	if err != nil {
+		return nil, false
+	}
+	pods := make([]models.Pod, 0, len(list))
+	for _, p := range list {
+		pods = append(pods, podFromAPI(p))
+	}
+	sort.Slice(pods, func(i, j int) bool {
+		if pods[i].Namespace != pods[j].Namespace {
+			return pods[i].Namespace < pods[j].Namespace
+		}
+		return pods[i].Name < pods[j].Name
+	})
+	return pods, true
+}
+
+// cachedNodes serves a node listing from the informer cache, reporting
+// false when the cluster has no synced informer or the selector cannot
+// be parsed.
+func (s *KubernetesService) cachedNodes(cluster, labelSelector string) ([]models.Node, bool) {
+	inf, ok := s.informer(cluster)
+	if !ok {
+		return nil, false
+	}
+	selector := labels.Everything()
+	if labelSelector != "" {
+		parsed, err := labels.Parse(labelSelector)
+		if err != nil {
+			return nil, false
+		}
+		selector = parsed
+	}
+	list, err := inf.nodeLister.List(selector)
+	if err != nil {
+		return nil, false
+	}
+	nodes := make([]models.Node, 0, len(list))
+	for _, n := range list {
+		nodes = append(nodes, s.nodeFromAPI(n))
+	}
+	sort.Slice(nodes, func(i, j int) bool { return nodes[i].Name < nodes[j].Name })
+	return nodes, true
+}
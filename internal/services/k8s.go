@@ -0,0 +1,1444 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"gopkg.in/yaml.v3"
+	corev1 "k8s.io/api/core/v1"
+	networkingv1 "k8s.io/api/networking/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	apiversion "k8s.io/apimachinery/pkg/version"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/rishabh998186/kdash-monitor/internal/models"
+)
+
+// KubernetesService maintains API clients for the configured clusters
+// and exposes typed views over their resources.
+type KubernetesService struct {
+	mu             sync.RWMutex
+	configPath     string
+	configs        []models.ClusterConfig
+	clients        map[string]kubernetes.Interface
+	versions       map[string]string
+	connectTimeout time.Duration
+
+	// disabledAlertKinds is carried from the config file for the
+	// collector to consult; immutable after construction.
+	disabledAlertKinds []string
+
+	// defaultNamespaces is the global soft namespace filter applied to
+	// clusters without their own; immutable after construction.
+	defaultNamespaces []string
+
+	// nodeRoleLabels lists extra label keys whose values count as node
+	// roles, for provider pool labels; immutable after construction.
+	nodeRoleLabels []string
+
+	// countSucceeded includes Succeeded pods in pod-summary totals.
+	// Off by default so completed Job pods don't inflate the count;
+	// they are still reported separately.
+	countSucceeded bool
+
+	// informers caches pods and nodes for clusters that opt in via
+	// UseInformers; guarded by mu.
+	informers map[string]*clusterInformer
+
+	drainMu sync.Mutex
+	drains  map[string]*DrainStatus
+
+	// nsCache holds each cluster's namespace names briefly, so the
+	// existence check on pod requests doesn't hit the API every time;
+	// guarded by nsMu.
+	nsMu    sync.Mutex
+	nsCache map[string]namespaceCacheEntry
+}
+
+// namespaceCacheTTL bounds how stale the cached namespace list may be.
+const namespaceCacheTTL = 30 * time.Second
+
+type namespaceCacheEntry struct {
+	names   map[string]bool
+	fetched time.Time
+}
+
+// DefaultConnectTimeout bounds the API-server connectivity check when
+// no override is configured.
+const DefaultConnectTimeout = 5 * time.Second
+
+// NewKubernetesService loads the cluster configuration from configPath
+// and initializes a client per enabled cluster. connectTimeout bounds
+// connectivity checks; zero or negative selects the default.
+func NewKubernetesService(configPath string, connectTimeout time.Duration) (*KubernetesService, error) {
+	cfg, err := loadConfigs(configPath)
+	if err != nil {
+		return nil, err
+	}
+	if connectTimeout <= 0 {
+		connectTimeout = DefaultConnectTimeout
+	}
+	if err := validateAlertKinds(cfg.DisabledAlertKinds); err != nil {
+		return nil, fmt.Errorf("cluster config %s: %w", configPath, err)
+	}
+	s := &KubernetesService{
+		configPath:         configPath,
+		configs:            cfg.Clusters,
+		disabledAlertKinds: cfg.DisabledAlertKinds,
+		defaultNamespaces:  cfg.DefaultNamespaces,
+		nodeRoleLabels:     cfg.NodeRoleLabels,
+		clients:            make(map[string]kubernetes.Interface),
+		versions:           make(map[string]string),
+		connectTimeout:     connectTimeout,
+		informers:          make(map[string]*clusterInformer),
+		drains:             make(map[string]*DrainStatus),
+	}
+	s.initializeClients()
+	return s, nil
+}
+
+// loadConfigs parses the cluster config at path, accepting YAML or,
+// when the file has a .json extension, JSON with the same shape.
+func loadConfigs(path string) (*models.ClustersConfig, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading cluster config %s: %w", path, err)
+	}
+	var cfg models.ClustersConfig
+	if strings.EqualFold(filepath.Ext(path), ".json") {
+		err = json.Unmarshal(data, &cfg)
+	} else {
+		err = yaml.Unmarshal(data, &cfg)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("parsing cluster config %s: %w", path, err)
+	}
+	if cfg.Discovery != nil {
+		discovered, err := discoverClusters(cfg.Discovery)
+		if err != nil {
+			return nil, fmt.Errorf("cluster config %s: %w", path, err)
+		}
+		explicit := make(map[string]bool, len(cfg.Clusters))
+		for _, c := range cfg.Clusters {
+			explicit[c.Name] = true
+		}
+		for _, c := range discovered {
+			if !explicit[c.Name] {
+				cfg.Clusters = append(cfg.Clusters, c)
+			}
+		}
+	}
+	if len(cfg.Clusters) == 0 {
+		return nil, fmt.Errorf("cluster config %s defines no clusters", path)
+	}
+	return &cfg, nil
+}
+
+// discoverClusters generates one cluster entry per kubeconfig context,
+// optionally narrowed by a context-name regular expression. Names and
+// display names derive from the context; Prometheus URLs come from the
+// template, or stay empty.
+func discoverClusters(d *models.DiscoveryConfig) ([]models.ClusterConfig, error) {
+	kubeconfig := d.Kubeconfig
+	if kubeconfig == "" {
+		kubeconfig = clientcmd.RecommendedHomeFile
+	}
+	kc, err := clientcmd.LoadFromFile(kubeconfig)
+	if err != nil {
+		return nil, fmt.Errorf("loading kubeconfig for discovery: %w", err)
+	}
+	var filter *regexp.Regexp
+	if d.ContextFilter != "" {
+		if filter, err = regexp.Compile(d.ContextFilter); err != nil {
+			return nil, fmt.Errorf("invalid discovery context filter: %w", err)
+		}
+	}
+	names := make([]string, 0, len(kc.Contexts))
+	for name := range kc.Contexts {
+		if filter != nil && !filter.MatchString(name) {
+			continue
+		}
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	clusters := make([]models.ClusterConfig, 0, len(names))
+	for _, name := range names {
+		promURL := ""
+		if d.PrometheusURLTemplate != "" {
+			promURL = strings.ReplaceAll(d.PrometheusURLTemplate, "{context}", name)
+		}
+		clusters = append(clusters, models.ClusterConfig{
+			Name:          name,
+			DisplayName:   name,
+			Kubeconfig:    kubeconfig,
+			Context:       name,
+			PrometheusURL: promURL,
+			Labels:        d.Labels,
+			Enabled:       true,
+		})
+	}
+	return clusters, nil
+}
+
+// initializeClients builds a clientset for every enabled cluster,
+// skipping (with a warning) any whose kubeconfig cannot be loaded.
+func (s *KubernetesService) initializeClients() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, cfg := range s.configs {
+		if !cfg.Enabled {
+			continue
+		}
+		client, err := buildClient(cfg)
+		if err != nil {
+			log.Printf("warning: skipping cluster %s: %v", cfg.Name, err)
+			continue
+		}
+		s.clients[cfg.Name] = client
+		if cfg.UseInformers {
+			s.startInformer(cfg.Name, client)
+		}
+	}
+}
+
+// clientRetryInterval is how often initialization is retried for
+// clusters whose clients could not be built at startup.
+const clientRetryInterval = time.Minute
+
+// StartClientRetry launches a background loop that keeps trying to
+// build clients for enabled clusters that failed to initialize, so a
+// cluster that was down at boot is picked up once it comes back.
+func (s *KubernetesService) StartClientRetry() {
+	go func() {
+		ticker := time.NewTicker(clientRetryInterval)
+		defer ticker.Stop()
+		for range ticker.C {
+			s.retryMissingClients()
+		}
+	}()
+}
+
+// retryMissingClients attempts one initialization pass over enabled
+// clusters that have no client yet.
+func (s *KubernetesService) retryMissingClients() {
+	s.mu.RLock()
+	var missing []models.ClusterConfig
+	for _, cfg := range s.configs {
+		if !cfg.Enabled {
+			continue
+		}
+		if _, ok := s.clients[cfg.Name]; !ok {
+			missing = append(missing, cfg)
+		}
+	}
+	s.mu.RUnlock()
+
+	for _, cfg := range missing {
+		client, err := buildClient(cfg)
+		if err != nil {
+			continue
+		}
+		s.mu.Lock()
+		s.clients[cfg.Name] = client
+		if cfg.UseInformers {
+			s.startInformer(cfg.Name, client)
+		}
+		s.mu.Unlock()
+		log.Printf("cluster %s connected after retry", cfg.Name)
+	}
+}
+
+// buildClient constructs a clientset from a cluster's kubeconfig and
+// context.
+func buildClient(cfg models.ClusterConfig) (kubernetes.Interface, error) {
+	kubeconfig := cfg.Kubeconfig
+	if kubeconfig == "" {
+		kubeconfig = clientcmd.RecommendedHomeFile
+	}
+	restCfg, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
+		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfig},
+		&clientcmd.ConfigOverrides{CurrentContext: cfg.Context},
+	).ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("loading kubeconfig: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(restCfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating clientset: %w", err)
+	}
+	return client, nil
+}
+
+// DisabledAlertKinds returns the alert kinds the config disables.
+func (s *KubernetesService) DisabledAlertKinds() []string {
+	return s.disabledAlertKinds
+}
+
+// SetCountSucceededPods restores the old behaviour of counting
+// Succeeded pods in pod-summary totals.
+func (s *KubernetesService) SetCountSucceededPods(count bool) {
+	s.countSucceeded = count
+}
+
+// GetConfigs returns the configured clusters.
+func (s *KubernetesService) GetConfigs() []models.ClusterConfig {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	out := make([]models.ClusterConfig, len(s.configs))
+	copy(out, s.configs)
+	return out
+}
+
+// GetConfig returns the configuration for a named cluster.
+func (s *KubernetesService) GetConfig(name string) (models.ClusterConfig, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	for _, cfg := range s.configs {
+		if cfg.Name == name {
+			return cfg, true
+		}
+	}
+	return models.ClusterConfig{}, false
+}
+
+// client returns the clientset for a named cluster.
+func (s *KubernetesService) client(cluster string) (kubernetes.Interface, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	c, ok := s.clients[cluster]
+	if !ok {
+		return nil, fmt.Errorf("no client for cluster %s", cluster)
+	}
+	return c, nil
+}
+
+// CheckConnectivity verifies the cluster's API server is reachable.
+func (s *KubernetesService) CheckConnectivity(ctx context.Context, cluster string) error {
+	ctx, span := startSpan(ctx, "k8s.CheckConnectivity", cluster)
+	defer span.End()
+
+	client, err := s.client(cluster)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, s.connectTimeout)
+	defer cancel()
+	_, err = client.Discovery().RESTClient().Get().AbsPath("/version").DoRaw(ctx)
+	if err != nil {
+		// A 403 means the API server answered; the account just cannot
+		// read this path. That is a permission problem, not an outage.
+		if apierrors.IsForbidden(err) {
+			return nil
+		}
+		// A 401 means the credentials were rejected — with exec-based
+		// auth usually an expired token, not a down cluster.
+		if apierrors.IsUnauthorized(err) {
+			return fmt.Errorf("cluster %s: %w: %v", cluster, ErrAuthRejected, err)
+		}
+		return fmt.Errorf("cluster %s unreachable: %w", cluster, err)
+	}
+	return nil
+}
+
+// ErrAuthRejected marks connectivity failures caused by the API server
+// rejecting our credentials rather than being unreachable, so callers
+// can treat token expiry differently from an outage.
+var ErrAuthRejected = errors.New("authentication rejected")
+
+// RebuildClient drops and rebuilds a cluster's client, forcing exec
+// credential plugins to run again. Rotated short-lived tokens are often
+// fixed by nothing more than a fresh exec invocation.
+func (s *KubernetesService) RebuildClient(cluster string) error {
+	cfg, ok := s.GetConfig(cluster)
+	if !ok {
+		return fmt.Errorf("no such cluster %s", cluster)
+	}
+	client, err := buildClient(cfg)
+	if err != nil {
+		return fmt.Errorf("rebuilding client for %s: %w", cluster, err)
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.clients[cluster] = client
+	if cfg.UseInformers {
+		s.stopInformer(cluster)
+		s.startInformer(cluster, client)
+	}
+	return nil
+}
+
+// clientConfigChanged reports whether two configs differ in a way that
+// invalidates a built client or its informer cache. Cosmetic fields
+// (display name, labels) and soft filters deliberately don't count, so
+// editing them doesn't reset warm connections.
+func clientConfigChanged(old, updated models.ClusterConfig) bool {
+	return old.Kubeconfig != updated.Kubeconfig ||
+		old.Context != updated.Context ||
+		old.Enabled != updated.Enabled ||
+		old.UseInformers != updated.UseInformers
+}
+
+// ReloadConfigs re-reads the cluster config file and applies it as a
+// diff: clients and informer caches are rebuilt only for clusters whose
+// connection-relevant config changed, added clusters get fresh clients,
+// and removed or disabled clusters are torn down. Unchanged clusters
+// keep their clients and warm caches, so a reload on a large fleet
+// doesn't cause a connection spike. Global settings (disabled alert
+// kinds, default namespaces) still require a restart.
+func (s *KubernetesService) ReloadConfigs() error {
+	cfg, err := loadConfigs(s.configPath)
+	if err != nil {
+		return err
+	}
+	if err := validateAlertKinds(cfg.DisabledAlertKinds); err != nil {
+		return fmt.Errorf("cluster config %s: %w", s.configPath, err)
+	}
+
+	s.mu.RLock()
+	previous := make(map[string]models.ClusterConfig, len(s.configs))
+	for _, c := range s.configs {
+		previous[c.Name] = c
+	}
+	s.mu.RUnlock()
+
+	// Build clients for new and changed clusters outside the lock, so a
+	// slow exec plugin doesn't stall every read during the reload.
+	built := make(map[string]kubernetes.Interface)
+	var added, rebuilt, unchanged int
+	for _, c := range cfg.Clusters {
+		if !c.Enabled {
+			continue
+		}
+		prev, existed := previous[c.Name]
+		if existed && !clientConfigChanged(prev, c) {
+			unchanged++
+			continue
+		}
+		client, err := buildClient(c)
+		if err != nil {
+			log.Printf("warning: skipping cluster %s on reload: %v", c.Name, err)
+			continue
+		}
+		built[c.Name] = client
+		if existed {
+			rebuilt++
+		} else {
+			added++
+		}
+	}
+
+	current := make(map[string]models.ClusterConfig, len(cfg.Clusters))
+	for _, c := range cfg.Clusters {
+		current[c.Name] = c
+	}
+
+	s.mu.Lock()
+	s.configs = cfg.Clusters
+	var removed int
+	for name := range s.clients {
+		if c, ok := current[name]; ok && c.Enabled {
+			continue
+		}
+		delete(s.clients, name)
+		delete(s.versions, name)
+		s.stopInformer(name)
+		removed++
+	}
+	for name, client := range built {
+		if _, replacing := s.clients[name]; replacing {
+			s.stopInformer(name)
+		}
+		s.clients[name] = client
+		delete(s.versions, name)
+		if current[name].UseInformers {
+			s.startInformer(name, client)
+		}
+	}
+	s.mu.Unlock()
+
+	log.Printf("config reload: %d added, %d rebuilt, %d removed, %d unchanged",
+		added, rebuilt, removed, unchanged)
+	return nil
+}
+
+// NamespaceExists reports whether a namespace exists in the cluster,
+// consulting a briefly cached namespace list. Accounts that cannot
+// list namespaces report true, erring on the side of serving the
+// request rather than rejecting it.
+func (s *KubernetesService) NamespaceExists(ctx context.Context, cluster, namespace string) (bool, error) {
+	s.nsMu.Lock()
+	entry, ok := s.nsCache[cluster]
+	s.nsMu.Unlock()
+	if !ok || time.Since(entry.fetched) > namespaceCacheTTL {
+		client, err := s.client(cluster)
+		if err != nil {
+			return false, err
+		}
+		list, err := client.CoreV1().Namespaces().List(ctx, metav1.ListOptions{})
+		if err != nil {
+			if apierrors.IsForbidden(err) {
+				return true, nil
+			}
+			return false, fmt.Errorf("listing namespaces for %s: %w", cluster, err)
+		}
+		names := make(map[string]bool, len(list.Items))
+		for _, ns := range list.Items {
+			names[ns.Name] = true
+		}
+		entry = namespaceCacheEntry{names: names, fetched: time.Now()}
+		s.nsMu.Lock()
+		if s.nsCache == nil {
+			s.nsCache = make(map[string]namespaceCacheEntry)
+		}
+		s.nsCache[cluster] = entry
+		s.nsMu.Unlock()
+	}
+	return entry.names[namespace], nil
+}
+
+// allowedNamespaces returns a cluster's configured namespace
+// restriction, or nil when the cluster is unrestricted.
+func (s *KubernetesService) allowedNamespaces(cluster string) []string {
+	if cfg, ok := s.GetConfig(cluster); ok {
+		return cfg.Namespaces
+	}
+	return nil
+}
+
+// namespaceDefaults returns the soft namespace filter for a cluster:
+// its own DefaultNamespaces, or the global default.
+func (s *KubernetesService) namespaceDefaults(cluster string) []string {
+	if cfg, ok := s.GetConfig(cluster); ok && len(cfg.DefaultNamespaces) > 0 {
+		return cfg.DefaultNamespaces
+	}
+	return s.defaultNamespaces
+}
+
+// namespaceIn reports whether the set contains the namespace.
+func namespaceIn(set []string, namespace string) bool {
+	for _, ns := range set {
+		if ns == namespace {
+			return true
+		}
+	}
+	return false
+}
+
+// GetServerVersion returns the cluster's Kubernetes git version and
+// platform. The result is cached since it changes only on upgrades.
+func (s *KubernetesService) GetServerVersion(ctx context.Context, cluster string) (string, error) {
+	s.mu.RLock()
+	cached, ok := s.versions[cluster]
+	s.mu.RUnlock()
+	if ok {
+		return cached, nil
+	}
+	client, err := s.client(cluster)
+	if err != nil {
+		return "", err
+	}
+	// Discovery's ServerVersion() takes no context, so an abandoned
+	// request would keep the fetch running; hitting /version through the
+	// REST client keeps it tied to request cancellation.
+	raw, err := client.Discovery().RESTClient().Get().AbsPath("/version").DoRaw(ctx)
+	if err != nil {
+		return "", fmt.Errorf("fetching server version for %s: %w", cluster, err)
+	}
+	var info apiversion.Info
+	if err := json.Unmarshal(raw, &info); err != nil {
+		return "", fmt.Errorf("parsing server version for %s: %w", cluster, err)
+	}
+	version := fmt.Sprintf("%s (%s)", info.GitVersion, info.Platform)
+	s.mu.Lock()
+	s.versions[cluster] = version
+	s.mu.Unlock()
+	return version, nil
+}
+
+// CheckControlPlane asks the API server about its own health via
+// /readyz, falling back to /healthz on servers too old to have it. A
+// nil error means the control plane reports healthy; a non-nil error
+// carries the server's explanation. This is distinct from
+// CheckConnectivity: a reachable API server can still report its etcd
+// or one of its checks as failing.
+func (s *KubernetesService) CheckControlPlane(ctx context.Context, cluster string) error {
+	client, err := s.client(cluster)
+	if err != nil {
+		return err
+	}
+	rest := client.Discovery().RESTClient()
+	_, err = rest.Get().AbsPath("/readyz").DoRaw(ctx)
+	if err == nil {
+		return nil
+	}
+	if apierrors.IsNotFound(err) {
+		_, herr := rest.Get().AbsPath("/healthz").DoRaw(ctx)
+		if herr == nil {
+			return nil
+		}
+		err = herr
+	}
+	return fmt.Errorf("control plane unhealthy for %s: %w", cluster, err)
+}
+
+// GetNodes lists the cluster's nodes, optionally restricted by a
+// Kubernetes label selector.
+func (s *KubernetesService) GetNodes(ctx context.Context, cluster, labelSelector string) ([]models.Node, error) {
+	ctx, span := startSpan(ctx, "k8s.GetNodes", cluster)
+	defer span.End()
+
+	if nodes, ok := s.cachedNodes(cluster, labelSelector); ok {
+		return nodes, nil
+	}
+	client, err := s.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	list, err := client.CoreV1().Nodes().List(ctx, metav1.ListOptions{LabelSelector: labelSelector})
+	if err != nil {
+		// Namespace-scoped accounts cannot list nodes; show none rather
+		// than failing every view that includes a node count.
+		if apierrors.IsForbidden(err) && len(s.allowedNamespaces(cluster)) > 0 {
+			return []models.Node{}, nil
+		}
+		return nil, fmt.Errorf("listing nodes for %s: %w", cluster, err)
+	}
+	nodes := make([]models.Node, 0, len(list.Items))
+	for i := range list.Items {
+		nodes = append(nodes, s.nodeFromAPI(&list.Items[i]))
+	}
+	return nodes, nil
+}
+
+// nodeFromAPI converts an API node to its model representation.
+func (s *KubernetesService) nodeFromAPI(n *corev1.Node) models.Node {
+	return models.Node{
+		Name:              n.Name,
+		Status:            getNodeStatus(*n),
+		Roles:             getNodeRoles(n.Labels, s.nodeRoleLabels),
+		Schedulable:       !n.Spec.Unschedulable,
+		CPUCapacity:       quantityValue(n.Status.Capacity, corev1.ResourceCPU),
+		CPUAllocatable:    quantityValue(n.Status.Allocatable, corev1.ResourceCPU),
+		MemoryCapacity:    quantityValue(n.Status.Capacity, corev1.ResourceMemory),
+		MemoryAllocatable: quantityValue(n.Status.Allocatable, corev1.ResourceMemory),
+		Version:           n.Status.NodeInfo.KubeletVersion,
+		Age:               formatAge(n.CreationTimestamp.Time),
+	}
+}
+
+// quantityValue reads one resource quantity from a resource list as a
+// float, zero when absent.
+func quantityValue(resources corev1.ResourceList, name corev1.ResourceName) float64 {
+	q, ok := resources[name]
+	if !ok {
+		return 0
+	}
+	return q.AsApproximateFloat64()
+}
+
+// CordonNode marks a node unschedulable.
+func (s *KubernetesService) CordonNode(ctx context.Context, cluster, node string) error {
+	return s.setUnschedulable(ctx, cluster, node, true)
+}
+
+// UncordonNode marks a node schedulable again.
+func (s *KubernetesService) UncordonNode(ctx context.Context, cluster, node string) error {
+	return s.setUnschedulable(ctx, cluster, node, false)
+}
+
+// setUnschedulable patches a node's spec.unschedulable field.
+func (s *KubernetesService) setUnschedulable(ctx context.Context, cluster, node string, unschedulable bool) error {
+	client, err := s.client(cluster)
+	if err != nil {
+		return err
+	}
+	patch := []byte(fmt.Sprintf(`{"spec":{"unschedulable":%t}}`, unschedulable))
+	_, err = client.CoreV1().Nodes().Patch(ctx, node, types.StrategicMergePatchType, patch, metav1.PatchOptions{})
+	if err != nil {
+		return fmt.Errorf("patching node %s on %s: %w", node, cluster, err)
+	}
+	return nil
+}
+
+// GetPods lists pods in the given namespace, or across the cluster's
+// default namespace set when namespace is empty. Passing "*" explicitly
+// requests all namespaces regardless of the default filter.
+func (s *KubernetesService) GetPods(ctx context.Context, cluster, namespace string) ([]models.Pod, error) {
+	ctx, span := startSpan(ctx, "k8s.GetPods", cluster)
+	defer span.End()
+
+	namespaces, err := s.scopedNamespaces(cluster, namespace)
+	if err != nil {
+		return nil, err
+	}
+	// The informer cache only answers single-scope requests; a default
+	// set fans out to per-namespace List calls like everything else.
+	if len(namespaces) == 1 {
+		if pods, ok := s.cachedPods(cluster, namespaces[0]); ok {
+			return pods, nil
+		}
+	}
+	client, err := s.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	var pods []models.Pod
+	for _, ns := range namespaces {
+		list, err := client.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing pods for %s: %w", cluster, err)
+		}
+		for i := range list.Items {
+			pods = append(pods, podFromAPI(&list.Items[i]))
+		}
+	}
+	return pods, nil
+}
+
+// GetPodsOnNode lists the pods scheduled on one node, across every
+// namespace the cluster allows. The API server filters server-side via
+// a field selector, so this stays cheap even on large clusters.
+func (s *KubernetesService) GetPodsOnNode(ctx context.Context, cluster, node string) ([]models.Pod, error) {
+	ctx, span := startSpan(ctx, "k8s.GetPodsOnNode", cluster)
+	defer span.End()
+
+	namespaces, err := s.scopedNamespaces(cluster, "*")
+	if err != nil {
+		return nil, err
+	}
+	client, err := s.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	opts := metav1.ListOptions{FieldSelector: "spec.nodeName=" + node}
+	var pods []models.Pod
+	for _, ns := range namespaces {
+		list, err := client.CoreV1().Pods(ns).List(ctx, opts)
+		if err != nil {
+			return nil, fmt.Errorf("listing pods on node %s of %s: %w", node, cluster, err)
+		}
+		for i := range list.Items {
+			pods = append(pods, podFromAPI(&list.Items[i]))
+		}
+	}
+	return pods, nil
+}
+
+// scopedNamespaces resolves which namespaces a listing must query:
+// the requested one, the cluster's allowed set for an all-namespace
+// request, or the empty namespace (meaning all) when unrestricted. An
+// empty request additionally falls back to the configured default
+// namespace filter, which "*" explicitly escapes. A request outside the
+// allowed set is refused outright.
+func (s *KubernetesService) scopedNamespaces(cluster, namespace string) ([]string, error) {
+	allowed := s.allowedNamespaces(cluster)
+	if len(allowed) == 0 {
+		if namespace == "" {
+			if defaults := s.namespaceDefaults(cluster); len(defaults) > 0 {
+				return defaults, nil
+			}
+		}
+		if namespace == "*" {
+			namespace = metav1.NamespaceAll
+		}
+		return []string{namespace}, nil
+	}
+	if namespace == "" || namespace == "*" {
+		return allowed, nil
+	}
+	if !namespaceIn(allowed, namespace) {
+		return nil, fmt.Errorf("namespace %s is not in the allowed set for cluster %s", namespace, cluster)
+	}
+	return []string{namespace}, nil
+}
+
+// podFromAPI converts an API pod to its model representation.
+func podFromAPI(p *corev1.Pod) models.Pod {
+	var restarts int32
+	containers := make([]models.ContainerStatus, 0, len(p.Status.ContainerStatuses))
+	for _, cs := range p.Status.ContainerStatuses {
+		restarts += cs.RestartCount
+		state, reason, message := containerState(cs)
+		containers = append(containers, models.ContainerStatus{
+			Name:     cs.Name,
+			Image:    cs.Image,
+			Ready:    cs.Ready,
+			Restarts: cs.RestartCount,
+			State:    state,
+			Reason:   reason,
+			Message:  message,
+		})
+	}
+	pod := models.Pod{
+		Name:       p.Name,
+		Namespace:  p.Namespace,
+		Status:     string(p.Status.Phase),
+		Node:       p.Spec.NodeName,
+		Restarts:   restarts,
+		Age:        formatAge(p.CreationTimestamp.Time),
+		AgeSeconds: int64(time.Since(p.CreationTimestamp.Time).Seconds()),
+		Containers: containers,
+	}
+	if p.Status.Phase == corev1.PodPending {
+		pod.Reason = pendingReason(*p)
+	}
+	for _, container := range p.Spec.Containers {
+		pod.CPURequest += quantityValue(container.Resources.Requests, corev1.ResourceCPU)
+		pod.CPULimit += quantityValue(container.Resources.Limits, corev1.ResourceCPU)
+		pod.MemoryRequest += quantityValue(container.Resources.Requests, corev1.ResourceMemory)
+		pod.MemoryLimit += quantityValue(container.Resources.Limits, corev1.ResourceMemory)
+	}
+	return pod
+}
+
+// containerState condenses a container's state into a name and, for
+// waiting/terminated containers, the reason and kubelet message.
+func containerState(cs corev1.ContainerStatus) (state, reason, message string) {
+	switch {
+	case cs.State.Running != nil:
+		return "running", "", ""
+	case cs.State.Waiting != nil:
+		return "waiting", cs.State.Waiting.Reason, cs.State.Waiting.Message
+	case cs.State.Terminated != nil:
+		return "terminated", cs.State.Terminated.Reason, cs.State.Terminated.Message
+	default:
+		return "unknown", "", ""
+	}
+}
+
+// pendingReason explains why a pending pod is stuck, preferring the
+// scheduler's condition and falling back to container waiting reasons.
+func pendingReason(p corev1.Pod) string {
+	for _, cond := range p.Status.Conditions {
+		if cond.Type == corev1.PodScheduled && cond.Status == corev1.ConditionFalse {
+			if cond.Message != "" {
+				return fmt.Sprintf("%s: %s", cond.Reason, cond.Message)
+			}
+			return cond.Reason
+		}
+	}
+	for _, cs := range p.Status.ContainerStatuses {
+		if cs.State.Waiting != nil && cs.State.Waiting.Reason != "" {
+			return cs.State.Waiting.Reason
+		}
+	}
+	return ""
+}
+
+// GetPodDetail returns the full view of a single pod, including its
+// conditions, QoS class, and the events that reference it.
+func (s *KubernetesService) GetPodDetail(ctx context.Context, cluster, namespace, pod string) (*models.PodDetail, error) {
+	client, err := s.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	p, err := client.CoreV1().Pods(namespace).Get(ctx, pod, metav1.GetOptions{})
+	if err != nil {
+		return nil, fmt.Errorf("fetching pod %s/%s: %w", namespace, pod, err)
+	}
+	detail := &models.PodDetail{
+		Pod:      podFromAPI(p),
+		QOSClass: string(p.Status.QOSClass),
+	}
+	detail.Conditions = make([]models.PodCondition, 0, len(p.Status.Conditions))
+	for _, cond := range p.Status.Conditions {
+		detail.Conditions = append(detail.Conditions, models.PodCondition{
+			Type:    string(cond.Type),
+			Status:  string(cond.Status),
+			Reason:  cond.Reason,
+			Message: cond.Message,
+		})
+	}
+	// Events are best-effort: a pod without them is still inspectable.
+	list, err := client.CoreV1().Events(namespace).List(ctx, metav1.ListOptions{
+		FieldSelector: fmt.Sprintf("involvedObject.kind=Pod,involvedObject.name=%s", pod),
+	})
+	if err != nil {
+		log.Printf("listing events for pod %s/%s in %s failed: %v", namespace, pod, cluster, err)
+		return detail, nil
+	}
+	detail.Events = make([]models.Event, 0, len(list.Items))
+	for _, e := range list.Items {
+		detail.Events = append(detail.Events, models.Event{
+			Type:     e.Type,
+			Reason:   e.Reason,
+			Object:   fmt.Sprintf("%s/%s", strings.ToLower(e.InvolvedObject.Kind), e.InvolvedObject.Name),
+			Message:  e.Message,
+			Count:    e.Count,
+			LastSeen: e.LastTimestamp.Time,
+		})
+	}
+	sort.Slice(detail.Events, func(i, j int) bool {
+		return detail.Events[i].LastSeen.After(detail.Events[j].LastSeen)
+	})
+	return detail, nil
+}
+
+// GetPodSummary counts the cluster's pods by phase.
+func (s *KubernetesService) GetPodSummary(ctx context.Context, cluster string) (*models.PodSummary, error) {
+	ctx, span := startSpan(ctx, "k8s.GetPodSummary", cluster)
+	defer span.End()
+
+	client, err := s.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := s.scopedNamespaces(cluster, metav1.NamespaceAll)
+	if err != nil {
+		return nil, err
+	}
+	summary := &models.PodSummary{}
+	for _, ns := range namespaces {
+		list, err := client.CoreV1().Pods(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing pods for %s: %w", cluster, err)
+		}
+		for _, p := range list.Items {
+			switch p.Status.Phase {
+			case corev1.PodRunning:
+				summary.Running++
+			case corev1.PodPending:
+				summary.Pending++
+				if reason := pendingReason(p); reason != "" {
+					if summary.PendingReasons == nil {
+						summary.PendingReasons = make(map[string]int)
+					}
+					summary.PendingReasons[reason]++
+				}
+			case corev1.PodFailed:
+				summary.Failed++
+			case corev1.PodSucceeded:
+				summary.Succeeded++
+				if !s.countSucceeded {
+					continue
+				}
+			}
+			summary.Total++
+		}
+	}
+	return summary, nil
+}
+
+// GetPersistentVolumeClaims lists PVCs in the given namespace, or
+// across the cluster's allowed namespace set when namespace is empty.
+func (s *KubernetesService) GetPersistentVolumeClaims(ctx context.Context, cluster, namespace string) ([]models.PVC, error) {
+	client, err := s.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := s.scopedNamespaces(cluster, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var pvcs []models.PVC
+	for _, ns := range namespaces {
+		list, err := client.CoreV1().PersistentVolumeClaims(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing pvcs for %s: %w", cluster, err)
+		}
+		for _, pvc := range list.Items {
+			capacity := ""
+			if qty, ok := pvc.Status.Capacity[corev1.ResourceStorage]; ok {
+				capacity = qty.String()
+			}
+			storageClass := ""
+			if pvc.Spec.StorageClassName != nil {
+				storageClass = *pvc.Spec.StorageClassName
+			}
+			pvcs = append(pvcs, models.PVC{
+				Name:         pvc.Name,
+				Namespace:    pvc.Namespace,
+				Status:       string(pvc.Status.Phase),
+				Capacity:     capacity,
+				StorageClass: storageClass,
+			})
+		}
+	}
+	return pvcs, nil
+}
+
+// GetEvents lists recent events in the given namespace, or across the
+// cluster's default namespace set when namespace is empty ("*" requests
+// all namespaces explicitly).
+func (s *KubernetesService) GetEvents(ctx context.Context, cluster, namespace string) ([]models.Event, error) {
+	client, err := s.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := s.scopedNamespaces(cluster, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var events []models.Event
+	for _, ns := range namespaces {
+		list, err := client.CoreV1().Events(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing events for %s: %w", cluster, err)
+		}
+		for _, e := range list.Items {
+			events = append(events, models.Event{
+				Type:     e.Type,
+				Reason:   e.Reason,
+				Object:   fmt.Sprintf("%s/%s", strings.ToLower(e.InvolvedObject.Kind), e.InvolvedObject.Name),
+				Message:  e.Message,
+				Count:    e.Count,
+				LastSeen: e.LastTimestamp.Time,
+			})
+		}
+	}
+	sort.Slice(events, func(i, j int) bool {
+		return events[i].LastSeen.After(events[j].LastSeen)
+	})
+	return events, nil
+}
+
+// GetDeployments lists deployments in the given namespace, or across
+// the cluster's allowed namespace set when namespace is empty.
+func (s *KubernetesService) GetDeployments(ctx context.Context, cluster, namespace string) ([]models.Deployment, error) {
+	client, err := s.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := s.scopedNamespaces(cluster, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var deployments []models.Deployment
+	for _, ns := range namespaces {
+		list, err := client.AppsV1().Deployments(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing deployments for %s: %w", cluster, err)
+		}
+		for _, d := range list.Items {
+			var desired int32
+			if d.Spec.Replicas != nil {
+				desired = *d.Spec.Replicas
+			}
+			images := make([]string, 0, len(d.Spec.Template.Spec.Containers))
+			for _, container := range d.Spec.Template.Spec.Containers {
+				images = append(images, container.Image)
+			}
+			deployments = append(deployments, models.Deployment{
+				Name:      d.Name,
+				Namespace: d.Namespace,
+				Desired:   desired,
+				Ready:     d.Status.ReadyReplicas,
+				Available: d.Status.AvailableReplicas,
+				Images:    images,
+				Age:       formatAge(d.CreationTimestamp.Time),
+			})
+		}
+	}
+	return deployments, nil
+}
+
+// GetServices lists services in the given namespace, or across the
+// cluster's allowed namespace set when namespace is empty.
+func (s *KubernetesService) GetServices(ctx context.Context, cluster, namespace string) ([]models.Service, error) {
+	client, err := s.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := s.scopedNamespaces(cluster, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var services []models.Service
+	for _, ns := range namespaces {
+		list, err := client.CoreV1().Services(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing services for %s: %w", cluster, err)
+		}
+		for _, svc := range list.Items {
+			externalIPs := append([]string{}, svc.Spec.ExternalIPs...)
+			for _, lb := range svc.Status.LoadBalancer.Ingress {
+				if lb.IP != "" {
+					externalIPs = append(externalIPs, lb.IP)
+				} else if lb.Hostname != "" {
+					externalIPs = append(externalIPs, lb.Hostname)
+				}
+			}
+			ports := make([]string, 0, len(svc.Spec.Ports))
+			for _, p := range svc.Spec.Ports {
+				port := fmt.Sprintf("%d/%s", p.Port, p.Protocol)
+				if p.NodePort != 0 {
+					port = fmt.Sprintf("%d:%d/%s", p.Port, p.NodePort, p.Protocol)
+				}
+				ports = append(ports, port)
+			}
+			services = append(services, models.Service{
+				Name:              svc.Name,
+				Namespace:         svc.Namespace,
+				Type:              string(svc.Spec.Type),
+				ClusterIP:         svc.Spec.ClusterIP,
+				ExternalIPs:       externalIPs,
+				Ports:             ports,
+				PendingExternalIP: svc.Spec.Type == corev1.ServiceTypeLoadBalancer && len(externalIPs) == 0,
+				Age:               formatAge(svc.CreationTimestamp.Time),
+			})
+		}
+	}
+	return services, nil
+}
+
+// GetIngresses lists ingresses in the given namespace, or across the
+// cluster's allowed namespace set when namespace is empty.
+func (s *KubernetesService) GetIngresses(ctx context.Context, cluster, namespace string) ([]models.Ingress, error) {
+	client, err := s.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := s.scopedNamespaces(cluster, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var ingresses []models.Ingress
+	for _, ns := range namespaces {
+		list, err := client.NetworkingV1().Ingresses(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing ingresses for %s: %w", cluster, err)
+		}
+		for _, ing := range list.Items {
+			class := ""
+			if ing.Spec.IngressClassName != nil {
+				class = *ing.Spec.IngressClassName
+			}
+			hosts := []string{}
+			backends := []models.IngressBackend{}
+			for _, rule := range ing.Spec.Rules {
+				if rule.Host != "" {
+					hosts = append(hosts, rule.Host)
+				}
+				if rule.HTTP == nil {
+					continue
+				}
+				for _, path := range rule.HTTP.Paths {
+					if path.Backend.Service == nil {
+						continue
+					}
+					port := path.Backend.Service.Port.Name
+					if port == "" {
+						port = fmt.Sprintf("%d", path.Backend.Service.Port.Number)
+					}
+					backends = append(backends, models.IngressBackend{
+						Service: path.Backend.Service.Name,
+						Port:    port,
+					})
+				}
+			}
+			ingresses = append(ingresses, models.Ingress{
+				Name:      ing.Name,
+				Namespace: ing.Namespace,
+				Class:     class,
+				Hosts:     hosts,
+				Backends:  backends,
+				Address:   loadBalancerAddress(ing.Status.LoadBalancer.Ingress),
+				Age:       formatAge(ing.CreationTimestamp.Time),
+			})
+		}
+	}
+	return ingresses, nil
+}
+
+// loadBalancerAddress returns the first assigned load balancer IP or
+// hostname, or "" when none has been provisioned yet.
+func loadBalancerAddress(ingress []networkingv1.IngressLoadBalancerIngress) string {
+	for _, lb := range ingress {
+		if lb.IP != "" {
+			return lb.IP
+		}
+		if lb.Hostname != "" {
+			return lb.Hostname
+		}
+	}
+	return ""
+}
+
+// GetDaemonSets lists daemon sets in the given namespace, or across the
+// cluster's allowed namespace set when namespace is empty.
+func (s *KubernetesService) GetDaemonSets(ctx context.Context, cluster, namespace string) ([]models.DaemonSet, error) {
+	client, err := s.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := s.scopedNamespaces(cluster, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var daemonsets []models.DaemonSet
+	for _, ns := range namespaces {
+		list, err := client.AppsV1().DaemonSets(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing daemonsets for %s: %w", cluster, err)
+		}
+		for _, ds := range list.Items {
+			daemonsets = append(daemonsets, models.DaemonSet{
+				Name:      ds.Name,
+				Namespace: ds.Namespace,
+				Desired:   ds.Status.DesiredNumberScheduled,
+				Ready:     ds.Status.NumberReady,
+				Available: ds.Status.NumberAvailable,
+				Degraded:  ds.Status.NumberReady < ds.Status.DesiredNumberScheduled,
+				Age:       formatAge(ds.CreationTimestamp.Time),
+			})
+		}
+	}
+	return daemonsets, nil
+}
+
+// GetStatefulSets lists stateful sets in the given namespace, or across
+// the cluster's allowed namespace set when namespace is empty.
+func (s *KubernetesService) GetStatefulSets(ctx context.Context, cluster, namespace string) ([]models.StatefulSet, error) {
+	client, err := s.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := s.scopedNamespaces(cluster, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var statefulsets []models.StatefulSet
+	for _, ns := range namespaces {
+		list, err := client.AppsV1().StatefulSets(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing statefulsets for %s: %w", cluster, err)
+		}
+		for _, ss := range list.Items {
+			var desired int32
+			if ss.Spec.Replicas != nil {
+				desired = *ss.Spec.Replicas
+			}
+			statefulsets = append(statefulsets, models.StatefulSet{
+				Name:      ss.Name,
+				Namespace: ss.Namespace,
+				Desired:   desired,
+				Ready:     ss.Status.ReadyReplicas,
+				Available: ss.Status.AvailableReplicas,
+				Degraded:  ss.Status.ReadyReplicas < desired,
+				Age:       formatAge(ss.CreationTimestamp.Time),
+			})
+		}
+	}
+	return statefulsets, nil
+}
+
+// GetJobs lists batch jobs in the given namespace, or across the
+// cluster's allowed namespace set when namespace is empty.
+func (s *KubernetesService) GetJobs(ctx context.Context, cluster, namespace string) ([]models.Job, error) {
+	client, err := s.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := s.scopedNamespaces(cluster, namespace)
+	if err != nil {
+		return nil, err
+	}
+	var jobs []models.Job
+	for _, ns := range namespaces {
+		list, err := client.BatchV1().Jobs(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing jobs for %s: %w", cluster, err)
+		}
+		for _, j := range list.Items {
+			completions := int32(1)
+			if j.Spec.Completions != nil {
+				completions = *j.Spec.Completions
+			}
+			var started *time.Time
+			if j.Status.StartTime != nil {
+				t := j.Status.StartTime.Time
+				started = &t
+			}
+			jobs = append(jobs, models.Job{
+				Name:        j.Name,
+				Namespace:   j.Namespace,
+				Completions: completions,
+				Succeeded:   j.Status.Succeeded,
+				Failed:      j.Status.Failed,
+				Active:      j.Status.Active,
+				Failing:     j.Status.Failed > 0,
+				StartTime:   started,
+				Age:         formatAge(j.CreationTimestamp.Time),
+			})
+		}
+	}
+	return jobs, nil
+}
+
+// GetCronJobs lists cron jobs in the given namespace, or across the
+// cluster's allowed namespace set when namespace is empty.
+func (s *KubernetesService) GetCronJobs(ctx context.Context, cluster, namespace string) ([]models.CronJob, error) {
+	client, err := s.client(cluster)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := s.scopedNamespaces(cluster, namespace)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	var cronjobs []models.CronJob
+	for _, ns := range namespaces {
+		list, err := client.BatchV1().CronJobs(ns).List(ctx, metav1.ListOptions{})
+		if err != nil {
+			return nil, fmt.Errorf("listing cronjobs for %s: %w", cluster, err)
+		}
+		for _, cj := range list.Items {
+			suspended := cj.Spec.Suspend != nil && *cj.Spec.Suspend
+			var lastSchedule *time.Time
+			ref := cj.CreationTimestamp.Time
+			if cj.Status.LastScheduleTime != nil {
+				t := cj.Status.LastScheduleTime.Time
+				lastSchedule = &t
+				ref = t
+			}
+			overdue := false
+			if bound := scheduleBound(cj.Spec.Schedule); !suspended && bound > 0 {
+				// Twice the expected gap leaves room for clock skew and
+				// slow controllers before we call a schedule missed.
+				overdue = now.Sub(ref) > 2*bound
+			}
+			cronjobs = append(cronjobs, models.CronJob{
+				Name:             cj.Name,
+				Namespace:        cj.Namespace,
+				Schedule:         cj.Spec.Schedule,
+				Suspended:        suspended,
+				Active:           len(cj.Status.Active),
+				LastScheduleTime: lastSchedule,
+				Overdue:          overdue,
+				Age:              formatAge(cj.CreationTimestamp.Time),
+			})
+		}
+	}
+	return cronjobs, nil
+}
+
+// scheduleBound returns a coarse upper bound on the gap between runs of
+// a cron schedule without pulling in a full cron parser: which fields
+// are unrestricted bound how long the schedule can go quiet. Unknown
+// expressions return 0, meaning no bound can be inferred.
+func scheduleBound(schedule string) time.Duration {
+	switch schedule {
+	case "@hourly":
+		return time.Hour
+	case "@daily", "@midnight":
+		return 24 * time.Hour
+	case "@weekly":
+		return 7 * 24 * time.Hour
+	case "@monthly":
+		return 31 * 24 * time.Hour
+	case "@yearly", "@annually":
+		return 366 * 24 * time.Hour
+	}
+	fields := strings.Fields(schedule)
+	if len(fields) != 5 {
+		return 0
+	}
+	free := func(f string) bool { return f == "*" || strings.HasPrefix(f, "*/") }
+	switch {
+	case !free(fields[3]):
+		return 366 * 24 * time.Hour
+	case free(fields[1]) && free(fields[2]) && free(fields[4]):
+		return time.Hour
+	case free(fields[2]) && free(fields[4]):
+		return 24 * time.Hour
+	case free(fields[2]):
+		return 7 * 24 * time.Hour
+	default:
+		return 31 * 24 * time.Hour
+	}
+}
+
+// GetPodLogs returns the tail of a container's logs. An empty container
+// selects the pod's only (or first) container; previous selects the
+// last terminated instance instead of the running one, which is where
+// the evidence lives when a container crash-loops.
+func (s *KubernetesService) GetPodLogs(ctx context.Context, cluster, namespace, pod, container string, tailLines int64, previous bool) (string, error) {
+	client, err := s.client(cluster)
+	if err != nil {
+		return "", err
+	}
+	opts := &corev1.PodLogOptions{TailLines: &tailLines, Previous: previous}
+	if container != "" {
+		opts.Container = container
+	}
+	stream, err := client.CoreV1().Pods(namespace).GetLogs(pod, opts).Stream(ctx)
+	if err != nil {
+		if previous {
+			return "", fmt.Errorf("fetching previous logs for %s/%s (the container may never have restarted): %w", namespace, pod, err)
+		}
+		return "", fmt.Errorf("fetching logs for %s/%s: %w", namespace, pod, err)
+	}
+	defer stream.Close()
+	data, err := io.ReadAll(stream)
+	if err != nil {
+		return "", fmt.Errorf("reading logs for %s/%s: %w", namespace, pod, err)
+	}
+	return string(data), nil
+}
+
+// getNodeStatus derives a node's Ready/NotReady status from its
+// conditions.
+func getNodeStatus(n corev1.Node) string {
+	for _, cond := range n.Status.Conditions {
+		if cond.Type == corev1.NodeReady {
+			if cond.Status == corev1.ConditionTrue {
+				return "Ready"
+			}
+			return "NotReady"
+		}
+	}
+	return "Unknown"
+}
+
+// getNodeRoles extracts role names from node-role.kubernetes.io labels
+// plus the values of any configured extra role labels (provider pool
+// labels like cloud.google.com/gke-nodepool), defaulting to "worker"
+// for nodes that match neither.
+func getNodeRoles(labels map[string]string, extraKeys []string) []string {
+	var roles []string
+	for key := range labels {
+		if strings.HasPrefix(key, "node-role.kubernetes.io/") {
+			roles = append(roles, strings.TrimPrefix(key, "node-role.kubernetes.io/"))
+		}
+	}
+	for _, key := range extraKeys {
+		if value := labels[key]; value != "" {
+			roles = append(roles, value)
+		}
+	}
+	if len(roles) == 0 {
+		roles = append(roles, "worker")
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// formatAge renders a creation time as a compact age like 3d or 5h.
+func formatAge(t time.Time) string {
+	d := time.Since(t)
+	switch {
+	case d >= 24*time.Hour:
+		return fmt.Sprintf("%dd", int(d.Hours()/24))
+	case d >= time.Hour:
+		return fmt.Sprintf("%dh", int(d.Hours()))
+	case d >= time.Minute:
+		return fmt.Sprintf("%dm", int(d.Minutes()))
+	default:
+		return fmt.Sprintf("%ds", int(d.Seconds()))
+	}
+}
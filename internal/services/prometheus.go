@@ -0,0 +1,634 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+)
+
+// Querier is the query transport the metric methods run PromQL
+// through. The production implementation speaks the Prometheus HTTP
+// API; tests inject a fake so the extraction logic can be exercised
+// without a live server.
+type Querier interface {
+	Query(ctx context.Context, baseURL, query string) ([]promSample, error)
+}
+
+// PrometheusService queries Prometheus for cluster metrics via a
+// pluggable query transport.
+type PrometheusService struct {
+	client  *http.Client
+	querier Querier
+
+	// overrides maps a Prometheus base URL to per-metric query
+	// substitutions, so deployments with recording rules can avoid the
+	// default raw expressions; guarded by mu.
+	mu        sync.RWMutex
+	overrides map[string]map[string]string
+}
+
+// DefaultPrometheusTimeout bounds individual Prometheus HTTP calls
+// when no override is configured.
+const DefaultPrometheusTimeout = 10 * time.Second
+
+// NewPrometheusService returns a PrometheusService whose HTTP calls are
+// bounded by timeout; zero or negative selects the default.
+func NewPrometheusService(timeout time.Duration) *PrometheusService {
+	if timeout <= 0 {
+		timeout = DefaultPrometheusTimeout
+	}
+	client := &http.Client{Timeout: timeout}
+	return &PrometheusService{
+		client:  client,
+		querier: &httpQuerier{client: client},
+	}
+}
+
+// SetQueryOverrides registers per-metric PromQL overrides for the
+// Prometheus at baseURL. Keys match the built-in metric names (cpu,
+// memory, disk, node-cpu, node-memory, node-disk); node-level
+// expressions may use a {node} placeholder. Precomputed recording
+// rules queried this way are far cheaper than the default irate
+// expressions on large clusters.
+func (s *PrometheusService) SetQueryOverrides(baseURL string, queries map[string]string) {
+	if baseURL == "" || len(queries) == 0 {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.overrides == nil {
+		s.overrides = make(map[string]map[string]string)
+	}
+	s.overrides[baseURL] = queries
+}
+
+// queryFor returns the registered override for a metric key with any
+// {node} placeholder expanded, or the default expression.
+func (s *PrometheusService) queryFor(baseURL, key, node, def string) string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if q, ok := s.overrides[baseURL][key]; ok && q != "" {
+		return strings.ReplaceAll(q, "{node}", node)
+	}
+	return def
+}
+
+// promSample is a single series returned by an instant query.
+type promSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  []interface{}     `json:"value"`
+}
+
+// promResponse is the envelope of the Prometheus query API.
+type promResponse struct {
+	Status string `json:"status"`
+	Data   struct {
+		ResultType string       `json:"resultType"`
+		Result     []promSample `json:"result"`
+	} `json:"data"`
+	Error string `json:"error"`
+}
+
+// CheckConnectivity verifies the Prometheus instance at baseURL is
+// reachable and healthy.
+func (s *PrometheusService) CheckConnectivity(ctx context.Context, baseURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, baseURL+"/-/healthy", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("prometheus unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("prometheus unhealthy: status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// Query runs an instant PromQL query through the configured transport
+// and returns the resulting series.
+func (s *PrometheusService) Query(ctx context.Context, baseURL, query string) ([]promSample, error) {
+	ctx, span := startSpan(ctx, "prometheus.Query", "")
+	span.SetAttributes(attribute.String("prometheus.url", baseURL), attribute.String("prometheus.query", query))
+	defer span.End()
+
+	return s.querier.Query(ctx, baseURL, query)
+}
+
+// httpQuerier is the production Querier, speaking the Prometheus HTTP
+// query API.
+type httpQuerier struct {
+	client *http.Client
+}
+
+func (q *httpQuerier) Query(ctx context.Context, baseURL, query string) ([]promSample, error) {
+	u := fmt.Sprintf("%s/api/v1/query?query=%s", baseURL, url.QueryEscape(query))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := q.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("querying prometheus: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("prometheus query failed: status %d", resp.StatusCode)
+	}
+	var pr promResponse
+	if err := json.NewDecoder(resp.Body).Decode(&pr); err != nil {
+		return nil, fmt.Errorf("decoding prometheus response: %w", err)
+	}
+	if pr.Status != "success" {
+		return nil, fmt.Errorf("prometheus query failed: %s", pr.Error)
+	}
+	return pr.Data.Result, nil
+}
+
+// matcherArg renders an extra label matcher for splicing into a
+// selector that already has matchers. Empty scope adds nothing, so
+// single-Prometheus setups keep the plain queries.
+func matcherArg(scope string) string {
+	if scope == "" {
+		return ""
+	}
+	return "," + scope
+}
+
+// GetCPUUsage returns the cluster-wide CPU utilization percentage.
+// scope is an optional label matcher narrowing a shared Prometheus to
+// this cluster's series.
+func (s *PrometheusService) GetCPUUsage(ctx context.Context, baseURL, scope string) (float64, error) {
+	query := s.queryFor(baseURL, "cpu", "", fmt.Sprintf(
+		`100 - avg(irate(node_cpu_seconds_total{mode="idle"%s}[5m])) * 100`, matcherArg(scope)))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// aggregateExpr wraps a per-node expression in the requested
+// aggregation. Supported: avg, max, p95, p99.
+func aggregateExpr(agg, perNode string) (string, error) {
+	switch agg {
+	case "", "avg":
+		return fmt.Sprintf("avg(%s)", perNode), nil
+	case "max":
+		return fmt.Sprintf("max(%s)", perNode), nil
+	case "p95":
+		return fmt.Sprintf("quantile(0.95, %s)", perNode), nil
+	case "p99":
+		return fmt.Sprintf("quantile(0.99, %s)", perNode), nil
+	default:
+		return "", fmt.Errorf("unsupported aggregation %q (want avg, max, p95, or p99)", agg)
+	}
+}
+
+// ValidAggregation reports whether agg names a supported cross-node
+// aggregation; the empty string means the default (avg).
+func ValidAggregation(agg string) bool {
+	_, err := aggregateExpr(agg, "x")
+	return err == nil
+}
+
+// perNodeCPUExpr and perNodeMemoryExpr yield one utilization sample per
+// node, for aggregation across the cluster. Averages hide outliers: a
+// cluster averaging 40% CPU can still have a node pegged at 100%, so
+// the max and high quantiles are exposed alongside the mean.
+func perNodeCPUExpr(scope string) string {
+	return fmt.Sprintf(`100 - avg by (instance) (irate(node_cpu_seconds_total{mode="idle"%s}[5m])) * 100`, matcherArg(scope))
+}
+
+func perNodeMemoryExpr(scope string) string {
+	return fmt.Sprintf(`100 * (1 - node_memory_MemAvailable_bytes{%s} / node_memory_MemTotal_bytes{%s})`, scope, scope)
+}
+
+// GetCPUUsageAgg returns cluster CPU utilization aggregated across
+// nodes with the given function (avg, max, p95, p99).
+func (s *PrometheusService) GetCPUUsageAgg(ctx context.Context, baseURL, agg, scope string) (float64, error) {
+	query, err := aggregateExpr(agg, perNodeCPUExpr(scope))
+	if err != nil {
+		return 0, err
+	}
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// GetMemoryUsageAgg returns cluster memory utilization aggregated
+// across nodes with the given function (avg, max, p95, p99).
+func (s *PrometheusService) GetMemoryUsageAgg(ctx context.Context, baseURL, agg, scope string) (float64, error) {
+	query, err := aggregateExpr(agg, perNodeMemoryExpr(scope))
+	if err != nil {
+		return 0, err
+	}
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// GetClusterCPUMax returns the busiest node's CPU utilization.
+func (s *PrometheusService) GetClusterCPUMax(ctx context.Context, baseURL, scope string) (float64, error) {
+	return s.GetCPUUsageAgg(ctx, baseURL, "max", scope)
+}
+
+// GetClusterMemoryMax returns the fullest node's memory utilization.
+func (s *PrometheusService) GetClusterMemoryMax(ctx context.Context, baseURL, scope string) (float64, error) {
+	return s.GetMemoryUsageAgg(ctx, baseURL, "max", scope)
+}
+
+// GetCPURequestedPercent returns cluster CPU requests as a percentage
+// of allocatable capacity, from kube-state-metrics. Utilization says
+// how busy the cores are; this says how much of the cluster is already
+// promised away, which is what capacity planning needs.
+func (s *PrometheusService) GetCPURequestedPercent(ctx context.Context, baseURL, scope string) (float64, error) {
+	query := fmt.Sprintf(
+		`100 * sum(kube_pod_container_resource_requests{resource="cpu"%s}) / sum(kube_node_status_allocatable{resource="cpu"%s})`,
+		matcherArg(scope), matcherArg(scope))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// GetAPIServerLatencyP99 returns the 99th-percentile apiserver request
+// latency in seconds, excluding long-lived WATCH and CONNECT verbs
+// that would dominate the histogram. Requires the API server's own
+// metrics to be scraped, which managed offerings often expose even
+// when node metrics are restricted.
+func (s *PrometheusService) GetAPIServerLatencyP99(ctx context.Context, baseURL, scope string) (float64, error) {
+	query := fmt.Sprintf(
+		`histogram_quantile(0.99, sum by (le) (rate(apiserver_request_duration_seconds_bucket{verb!~"WATCH|CONNECT"%s}[5m])))`,
+		matcherArg(scope))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// GetMemoryRequestedPercent is the memory counterpart of
+// GetCPURequestedPercent: memory requests as a percentage of
+// allocatable capacity.
+func (s *PrometheusService) GetMemoryRequestedPercent(ctx context.Context, baseURL, scope string) (float64, error) {
+	query := fmt.Sprintf(
+		`100 * sum(kube_pod_container_resource_requests{resource="memory"%s}) / sum(kube_node_status_allocatable{resource="memory"%s})`,
+		matcherArg(scope), matcherArg(scope))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// GetMemoryUsage returns the cluster-wide memory utilization percentage.
+func (s *PrometheusService) GetMemoryUsage(ctx context.Context, baseURL, scope string) (float64, error) {
+	query := s.queryFor(baseURL, "memory", "", fmt.Sprintf(
+		`100 * (1 - sum(node_memory_MemAvailable_bytes{%s}) / sum(node_memory_MemTotal_bytes{%s}))`, scope, scope))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// GetDiskUsage returns the fullest filesystem utilization percentage
+// across the cluster, ignoring pseudo-filesystems. The max (rather than
+// an average) is used because one full disk takes a node down
+// regardless of how empty the others are.
+func (s *PrometheusService) GetDiskUsage(ctx context.Context, baseURL, scope string) (float64, error) {
+	query := s.queryFor(baseURL, "disk", "", fmt.Sprintf(
+		`max(100 * (1 - node_filesystem_avail_bytes{fstype!~"tmpfs|overlay"%s} / node_filesystem_size_bytes{fstype!~"tmpfs|overlay"%s}))`,
+		matcherArg(scope), matcherArg(scope)))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// GetNodeCPUUsage returns the CPU utilization percentage for a single
+// node, matched by instance label.
+func (s *PrometheusService) GetNodeCPUUsage(ctx context.Context, baseURL, node, scope string) (float64, error) {
+	query := s.queryFor(baseURL, "node-cpu", node, fmt.Sprintf(
+		`100 - avg(irate(node_cpu_seconds_total{mode="idle",instance=~"%s.*"%s}[5m])) * 100`, node, matcherArg(scope)))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// GetNodeMemoryUsage returns the memory utilization percentage for a
+// single node, matched by instance label.
+func (s *PrometheusService) GetNodeMemoryUsage(ctx context.Context, baseURL, node, scope string) (float64, error) {
+	query := s.queryFor(baseURL, "node-memory", node, fmt.Sprintf(
+		`100 * (1 - node_memory_MemAvailable_bytes{instance=~"%s.*"%s} / node_memory_MemTotal_bytes{instance=~"%s.*"%s})`,
+		node, matcherArg(scope), node, matcherArg(scope)))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// GetNodeDiskUsage returns the fullest filesystem utilization
+// percentage on a single node, matched by instance label.
+func (s *PrometheusService) GetNodeDiskUsage(ctx context.Context, baseURL, node, scope string) (float64, error) {
+	query := s.queryFor(baseURL, "node-disk", node, fmt.Sprintf(
+		`max(100 * (1 - node_filesystem_avail_bytes{instance=~"%s.*",fstype!~"tmpfs|overlay"%s} / node_filesystem_size_bytes{instance=~"%s.*",fstype!~"tmpfs|overlay"%s}))`,
+		node, matcherArg(scope), node, matcherArg(scope)))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// GetNodeNetworkRx returns a node's receive throughput in bytes per
+// second, excluding the loopback device.
+func (s *PrometheusService) GetNodeNetworkRx(ctx context.Context, baseURL, node, scope string) (float64, error) {
+	query := fmt.Sprintf(
+		`sum(rate(node_network_receive_bytes_total{instance=~"%s.*",device!="lo"%s}[5m]))`, node, matcherArg(scope))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// GetNodeNetworkTx returns a node's transmit throughput in bytes per
+// second, excluding the loopback device.
+func (s *PrometheusService) GetNodeNetworkTx(ctx context.Context, baseURL, node, scope string) (float64, error) {
+	query := fmt.Sprintf(
+		`sum(rate(node_network_transmit_bytes_total{instance=~"%s.*",device!="lo"%s}[5m]))`, node, matcherArg(scope))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// GetPodCPUUsage returns a pod's CPU usage in cores.
+func (s *PrometheusService) GetPodCPUUsage(ctx context.Context, baseURL, namespace, pod, scope string) (float64, error) {
+	query := fmt.Sprintf(
+		`sum(rate(container_cpu_usage_seconds_total{namespace="%s",pod="%s",container!=""%s}[5m]))`,
+		namespace, pod, matcherArg(scope))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// GetPodMemoryUsage returns a pod's working-set memory in bytes.
+func (s *PrometheusService) GetPodMemoryUsage(ctx context.Context, baseURL, namespace, pod, scope string) (float64, error) {
+	query := fmt.Sprintf(
+		`sum(container_memory_working_set_bytes{namespace="%s",pod="%s",container!=""%s})`,
+		namespace, pod, matcherArg(scope))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// GetPodCPUUsageByPod returns CPU usage in cores for every pod in the
+// given namespace (all namespaces when empty), keyed by "namespace/pod".
+// Grouping by pod name alone would merge same-named pods from different
+// namespaces into one bogus series.
+func (s *PrometheusService) GetPodCPUUsageByPod(ctx context.Context, baseURL, namespace, scope string) (map[string]float64, error) {
+	sel := `container!=""`
+	if namespace != "" {
+		sel += fmt.Sprintf(`,namespace="%s"`, namespace)
+	}
+	sel += matcherArg(scope)
+	query := fmt.Sprintf(`sum by (namespace, pod) (rate(container_cpu_usage_seconds_total{%s}[5m]))`, sel)
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.extractByNamespacedPod(result)
+}
+
+// GetPodMemoryUsageByPod returns working-set memory in bytes for every
+// pod in the given namespace (all namespaces when empty), keyed by
+// "namespace/pod".
+func (s *PrometheusService) GetPodMemoryUsageByPod(ctx context.Context, baseURL, namespace, scope string) (map[string]float64, error) {
+	sel := `container!=""`
+	if namespace != "" {
+		sel += fmt.Sprintf(`,namespace="%s"`, namespace)
+	}
+	sel += matcherArg(scope)
+	query := fmt.Sprintf(`sum by (namespace, pod) (container_memory_working_set_bytes{%s})`, sel)
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.extractByNamespacedPod(result)
+}
+
+// RestartRateWindow is the lookback for restart-rate queries: recent
+// enough that a pod crash-looping right now stands out, long enough to
+// catch back-off intervals between restarts.
+const RestartRateWindow = 15 * time.Minute
+
+// GetPodRestartRatesByPod returns each pod's restart count over the
+// last RestartRateWindow, from the kube-state-metrics restarts counter,
+// keyed by "namespace/pod" (all namespaces when namespace is empty).
+// Unlike the cumulative count on the pod itself, this only reflects
+// current churn.
+func (s *PrometheusService) GetPodRestartRatesByPod(ctx context.Context, baseURL, namespace, scope string) (map[string]float64, error) {
+	var matchers []string
+	if namespace != "" {
+		matchers = append(matchers, fmt.Sprintf(`namespace="%s"`, namespace))
+	}
+	if scope != "" {
+		matchers = append(matchers, scope)
+	}
+	query := fmt.Sprintf(`sum by (namespace, pod) (increase(kube_pod_container_status_restarts_total{%s}[%dm]))`,
+		strings.Join(matchers, ","), int(RestartRateWindow.Minutes()))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return nil, err
+	}
+	return s.extractByNamespacedPod(result)
+}
+
+// PodUsage is one entry of a top-consumers query.
+type PodUsage struct {
+	Namespace string  `json:"namespace"`
+	Pod       string  `json:"pod"`
+	Value     float64 `json:"value"`
+}
+
+// GetTopPods returns the limit highest-consuming pods for a resource,
+// "cpu" (cores) or "memory" (bytes), highest first. The ranking runs as
+// a topk query so Prometheus does the sorting instead of the API
+// shipping every pod's usage over the wire.
+func (s *PrometheusService) GetTopPods(ctx context.Context, baseURL, resource string, limit int, scope string) ([]PodUsage, error) {
+	var inner string
+	switch resource {
+	case "cpu":
+		inner = fmt.Sprintf(`sum by (namespace, pod) (rate(container_cpu_usage_seconds_total{container!=""%s}[5m]))`, matcherArg(scope))
+	case "memory":
+		inner = fmt.Sprintf(`sum by (namespace, pod) (container_memory_working_set_bytes{container!=""%s})`, matcherArg(scope))
+	default:
+		return nil, fmt.Errorf("unsupported resource %q, expected cpu or memory", resource)
+	}
+	result, err := s.Query(ctx, baseURL, fmt.Sprintf(`topk(%d, %s)`, limit, inner))
+	if err != nil {
+		return nil, err
+	}
+	top := make([]PodUsage, 0, len(result))
+	for _, sample := range result {
+		value, err := sampleValue(sample)
+		if err != nil {
+			continue
+		}
+		top = append(top, PodUsage{
+			Namespace: sample.Metric["namespace"],
+			Pod:       sample.Metric["pod"],
+			Value:     value,
+		})
+	}
+	sort.Slice(top, func(i, j int) bool { return top[i].Value > top[j].Value })
+	return top, nil
+}
+
+// GetPodCPUThrottling returns the share of CPU scheduling periods in
+// which a pod was throttled, as a percentage. Throttling is invisible
+// in plain usage numbers yet a common cause of latency, so the pod
+// detail view surfaces it directly. Pods without CPU limits export no
+// throttling series; that comes back as ErrNoData rather than a
+// misleading zero.
+func (s *PrometheusService) GetPodCPUThrottling(ctx context.Context, baseURL, namespace, pod, scope string) (float64, error) {
+	query := fmt.Sprintf(
+		`100 * sum(rate(container_cpu_cfs_throttled_periods_total{namespace="%s",pod="%s",container!=""%s}[5m])) / sum(rate(container_cpu_cfs_periods_total{namespace="%s",pod="%s",container!=""%s}[5m]))`,
+		namespace, pod, matcherArg(scope), namespace, pod, matcherArg(scope))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// GetPVCUsedPercent returns how full a persistent volume claim is,
+// from the kubelet volume stats metrics.
+func (s *PrometheusService) GetPVCUsedPercent(ctx context.Context, baseURL, namespace, pvc, scope string) (float64, error) {
+	query := fmt.Sprintf(
+		`100 * kubelet_volume_stats_used_bytes{namespace="%s",persistentvolumeclaim="%s"%s} / kubelet_volume_stats_capacity_bytes{namespace="%s",persistentvolumeclaim="%s"%s}`,
+		namespace, pvc, matcherArg(scope), namespace, pvc, matcherArg(scope))
+	result, err := s.Query(ctx, baseURL, query)
+	if err != nil {
+		return 0, err
+	}
+	return s.extractFirstValue(result)
+}
+
+// ErrNoData reports a query that succeeded but matched no series, so
+// callers can distinguish an absent metric from a failed query.
+var ErrNoData = errors.New("no data returned")
+
+// extractFirstValue returns the value of a query expected to yield a
+// single series. Misconfigured relabeling can make several exporters
+// share one instance label, returning duplicate series; those are
+// averaged so the reported number is stable between refreshes instead
+// of depending on response ordering.
+func (s *PrometheusService) extractFirstValue(result []promSample) (float64, error) {
+	if len(result) == 0 {
+		return 0, ErrNoData
+	}
+	if len(result) == 1 {
+		return sampleValue(result[0])
+	}
+	log.Printf("warning: query expected one series but returned %d, averaging duplicates", len(result))
+	var sum float64
+	for _, sample := range result {
+		value, err := sampleValue(sample)
+		if err != nil {
+			return 0, err
+		}
+		sum += value
+	}
+	return sum / float64(len(result)), nil
+}
+
+// extractByLabel maps every series in a grouped query result from its
+// labelName value to its sample value, for queries that aggregate by a
+// label (per node, per namespace, per pod). Series without the label
+// are skipped rather than treated as errors.
+func (s *PrometheusService) extractByLabel(result []promSample, labelName string) (map[string]float64, error) {
+	out := make(map[string]float64, len(result))
+	for _, sample := range result {
+		key, ok := sample.Metric[labelName]
+		if !ok {
+			continue
+		}
+		value, err := sampleValue(sample)
+		if err != nil {
+			return nil, fmt.Errorf("sample for %s=%q: %w", labelName, key, err)
+		}
+		out[key] = value
+	}
+	return out, nil
+}
+
+// extractByNamespacedPod maps every series of a query grouped by
+// (namespace, pod) to its "namespace/pod" key, so pods sharing a name
+// across namespaces stay distinct. Series missing either label are
+// skipped.
+func (s *PrometheusService) extractByNamespacedPod(result []promSample) (map[string]float64, error) {
+	out := make(map[string]float64, len(result))
+	for _, sample := range result {
+		namespace, ok := sample.Metric["namespace"]
+		if !ok {
+			continue
+		}
+		pod, ok := sample.Metric["pod"]
+		if !ok {
+			continue
+		}
+		value, err := sampleValue(sample)
+		if err != nil {
+			return nil, fmt.Errorf("sample for pod %q: %w", namespace+"/"+pod, err)
+		}
+		out[namespace+"/"+pod] = value
+	}
+	return out, nil
+}
+
+// sampleValue decodes the [timestamp, value] pair of a sample; the
+// value arrives as a JSON string.
+func sampleValue(sample promSample) (float64, error) {
+	if len(sample.Value) < 2 {
+		return 0, fmt.Errorf("malformed sample value")
+	}
+	switch v := sample.Value[1].(type) {
+	case string:
+		return strconv.ParseFloat(v, 64)
+	case float64:
+		return v, nil
+	default:
+		return 0, fmt.Errorf("unexpected sample value type %T", v)
+	}
+}
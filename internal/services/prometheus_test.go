@@ -0,0 +1,169 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// sample builds a promSample with the given instance label and value.
+func sample(instance string, value string) promSample {
+	return promSample{
+		Metric: map[string]string{"instance": instance},
+		Value:  []interface{}{float64(1700000000), value},
+	}
+}
+
+func TestExtractFirstValueSingleSeries(t *testing.T) {
+	s := &PrometheusService{}
+	got, err := s.extractFirstValue([]promSample{sample("node-1:9100", "42.5")})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != 42.5 {
+		t.Errorf("got %v, want 42.5", got)
+	}
+}
+
+func TestExtractFirstValueAveragesDuplicateSeries(t *testing.T) {
+	s := &PrometheusService{}
+	// Two exporters sharing an instance label must yield a stable
+	// average, not whichever series happens to come first.
+	result := []promSample{
+		sample("node-1:9100", "40"),
+		sample("node-1:9100", "60"),
+	}
+	got, err := s.extractFirstValue(result)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != 50 {
+		t.Errorf("got %v, want 50", got)
+	}
+}
+
+func TestExtractFirstValueEmptyResult(t *testing.T) {
+	s := &PrometheusService{}
+	if _, err := s.extractFirstValue(nil); err == nil {
+		t.Error("expected an error for an empty result")
+	}
+}
+
+// fakeQuerier returns canned results or errors and records the last
+// query, so metric methods can be tested without a live Prometheus.
+type fakeQuerier struct {
+	result    []promSample
+	err       error
+	lastQuery string
+}
+
+func (f *fakeQuerier) Query(ctx context.Context, baseURL, query string) ([]promSample, error) {
+	f.lastQuery = query
+	return f.result, f.err
+}
+
+func TestGetCPUUsage(t *testing.T) {
+	authErr := errors.New("prometheus query failed: status 401")
+	tests := []struct {
+		name    string
+		result  []promSample
+		err     error
+		want    float64
+		wantErr error
+	}{
+		{name: "single series", result: []promSample{sample("node-1:9100", "37.5")}, want: 37.5},
+		{name: "multi-series averaged", result: []promSample{sample("a:9100", "20"), sample("b:9100", "40")}, want: 30},
+		{name: "empty result", result: nil, wantErr: ErrNoData},
+		{name: "auth failure propagated", err: authErr, wantErr: authErr},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &PrometheusService{querier: &fakeQuerier{result: tt.result, err: tt.err}}
+			got, err := s.GetCPUUsage(context.Background(), "http://prom", "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetNodeCPUUsage(t *testing.T) {
+	tests := []struct {
+		name    string
+		node    string
+		result  []promSample
+		want    float64
+		wantErr error
+	}{
+		{name: "single series", node: "node-1", result: []promSample{sample("node-1:9100", "88")}, want: 88},
+		{name: "empty result", node: "node-2", wantErr: ErrNoData},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			fake := &fakeQuerier{result: tt.result}
+			s := &PrometheusService{querier: fake}
+			got, err := s.GetNodeCPUUsage(context.Background(), "http://prom", tt.node, "")
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("got error %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && got != tt.want {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+			if !strings.Contains(fake.lastQuery, tt.node) {
+				t.Errorf("query %q does not target node %q", fake.lastQuery, tt.node)
+			}
+		})
+	}
+}
+
+func TestGetPodCPUUsageByPodScopesNamespace(t *testing.T) {
+	fake := &fakeQuerier{result: []promSample{
+		{Metric: map[string]string{"namespace": "payments", "pod": "api-0"}, Value: []interface{}{float64(1700000000), "0.25"}},
+		{Metric: map[string]string{"namespace": "payments", "pod": "api-1"}, Value: []interface{}{float64(1700000000), "0.5"}},
+	}}
+	s := &PrometheusService{querier: fake}
+	got, err := s.GetPodCPUUsageByPod(context.Background(), "http://prom", "payments", "")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(got) != 2 || got["payments/api-0"] != 0.25 || got["payments/api-1"] != 0.5 {
+		t.Errorf("got %v, want payments/api-0=0.25 payments/api-1=0.5", got)
+	}
+	if !strings.Contains(fake.lastQuery, `namespace="payments"`) {
+		t.Errorf("query %q is not scoped to the namespace", fake.lastQuery)
+	}
+}
+
+func TestQueryObservesContextCancellation(t *testing.T) {
+	// A server that never answers until the client gives up, standing in
+	// for a slow Prometheus behind an abandoned browser request.
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer server.Close()
+
+	s := NewPrometheusService(time.Minute)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() {
+		_, err := s.GetCPUUsage(ctx, server.URL, "")
+		done <- err
+	}()
+	cancel()
+
+	select {
+	case err := <-done:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("got %v, want a context.Canceled error", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("query did not return after the context was cancelled")
+	}
+}
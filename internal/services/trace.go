@@ -0,0 +1,21 @@
+package services
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/rishabh998186/kdash-monitor/internal/tracing"
+)
+
+// startSpan opens a child span for a service call, tagged with the
+// cluster it targets. It is a no-op unless tracing is configured.
+func startSpan(ctx context.Context, name, cluster string) (context.Context, trace.Span) {
+	ctx, span := otel.Tracer(tracing.TracerName).Start(ctx, name)
+	if cluster != "" {
+		span.SetAttributes(attribute.String("cluster", cluster))
+	}
+	return ctx, span
+}
@@ -0,0 +1,460 @@
+package storage
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+
+	"github.com/rishabh998186/kdash-monitor/internal/models"
+)
+
+// MetricsStore persists metric snapshots and alerts in SQLite.
+type MetricsStore struct {
+	db *gorm.DB
+}
+
+// InMemoryDBPath selects an ephemeral in-memory database instead of a
+// file, for test and throwaway deployments.
+const InMemoryDBPath = ":memory:"
+
+// NewMetricsStore opens (or creates) the SQLite database at path and
+// runs migrations. Pass InMemoryDBPath for an ephemeral database.
+func NewMetricsStore(path string) (*MetricsStore, error) {
+	if path != InMemoryDBPath {
+		if err := validateDBPath(path); err != nil {
+			return nil, err
+		}
+	}
+	db, err := gorm.Open(sqlite.Open(path), &gorm.Config{
+		Logger: logger.Default.LogMode(logger.Silent),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("opening database %s: %w", path, err)
+	}
+	if err := db.AutoMigrate(&models.MetricSnapshot{}, &models.NodeSnapshot{}, &models.Alert{}, &models.StatusTransition{}, &models.Silence{}, &models.ClusterThresholds{}, &models.NotificationLog{}, &models.MetricAnnotation{}); err != nil {
+		return nil, fmt.Errorf("migrating database: %w", err)
+	}
+	// Rows created before first/last-seen tracking only carry Timestamp;
+	// backfill so the new fields are always populated.
+	if err := db.Exec("UPDATE alerts SET first_seen = timestamp, last_seen = timestamp WHERE first_seen IS NULL").Error; err != nil {
+		return nil, fmt.Errorf("backfilling alert timestamps: %w", err)
+	}
+	return &MetricsStore{db: db}, nil
+}
+
+// validateDBPath catches database path misconfigurations at startup,
+// where the error can name the real problem, instead of surfacing later
+// as an opaque driver error.
+func validateDBPath(path string) error {
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return fmt.Errorf("database path %s is a directory", path)
+	}
+	dir := filepath.Dir(path)
+	probe, err := os.CreateTemp(dir, ".kdash-write-check-")
+	if err != nil {
+		return fmt.Errorf("data directory %s is not writable: %w", dir, err)
+	}
+	probe.Close()
+	os.Remove(probe.Name())
+	return nil
+}
+
+// Ping verifies the underlying database connection is usable.
+func (s *MetricsStore) Ping() error {
+	sqlDB, err := s.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.Ping()
+}
+
+// SaveSnapshot persists one collected snapshot.
+func (s *MetricsStore) SaveSnapshot(snap *models.MetricSnapshot) error {
+	return s.db.Create(snap).Error
+}
+
+// GetSnapshots returns a cluster's snapshots since the given time,
+// oldest first.
+func (s *MetricsStore) GetSnapshots(cluster string, since time.Time) ([]models.MetricSnapshot, error) {
+	var snaps []models.MetricSnapshot
+	err := s.db.
+		Where("cluster = ? AND timestamp >= ?", cluster, since).
+		Order("timestamp asc").
+		Find(&snaps).Error
+	return snaps, err
+}
+
+// GetSnapshotsRange returns a cluster's snapshots between from and to,
+// oldest first.
+func (s *MetricsStore) GetSnapshotsRange(cluster string, from, to time.Time) ([]models.MetricSnapshot, error) {
+	var snaps []models.MetricSnapshot
+	err := s.db.
+		Where("cluster = ? AND timestamp >= ? AND timestamp <= ?", cluster, from, to).
+		Order("timestamp asc").
+		Find(&snaps).Error
+	return snaps, err
+}
+
+// GetSnapshotsForClusters returns snapshots since the given time for
+// every named cluster in one query, keyed by cluster and oldest first
+// within each. Fleet-wide views use this instead of N per-cluster
+// round-trips; the single-cluster GetSnapshots remains for detail
+// pages.
+func (s *MetricsStore) GetSnapshotsForClusters(clusters []string, since time.Time) (map[string][]models.MetricSnapshot, error) {
+	out := make(map[string][]models.MetricSnapshot, len(clusters))
+	if len(clusters) == 0 {
+		return out, nil
+	}
+	var snaps []models.MetricSnapshot
+	err := s.db.
+		Where("cluster IN ? AND timestamp >= ?", clusters, since).
+		Order("timestamp asc").
+		Find(&snaps).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, snap := range snaps {
+		out[snap.Cluster] = append(out[snap.Cluster], snap)
+	}
+	return out, nil
+}
+
+// SaveNodeSnapshot persists one collected node snapshot.
+func (s *MetricsStore) SaveNodeSnapshot(snap *models.NodeSnapshot) error {
+	return s.db.Create(snap).Error
+}
+
+// GetNodeSnapshots returns a node's snapshots since the given time,
+// oldest first.
+func (s *MetricsStore) GetNodeSnapshots(cluster, node string, since time.Time) ([]models.NodeSnapshot, error) {
+	var snaps []models.NodeSnapshot
+	err := s.db.
+		Where("cluster = ? AND node = ? AND timestamp >= ?", cluster, node, since).
+		Order("timestamp asc").
+		Find(&snaps).Error
+	return snaps, err
+}
+
+// GetSnapshotNearest returns the cluster snapshot closest in time to
+// at, looking on both sides, or nil when the cluster has no snapshots.
+func (s *MetricsStore) GetSnapshotNearest(cluster string, at time.Time) (*models.MetricSnapshot, error) {
+	var before, after models.MetricSnapshot
+	errBefore := s.db.
+		Where("cluster = ? AND timestamp <= ?", cluster, at).
+		Order("timestamp desc").
+		First(&before).Error
+	errAfter := s.db.
+		Where("cluster = ? AND timestamp > ?", cluster, at).
+		Order("timestamp asc").
+		First(&after).Error
+	switch {
+	case errBefore == nil && errAfter == nil:
+		if at.Sub(before.Timestamp) <= after.Timestamp.Sub(at) {
+			return &before, nil
+		}
+		return &after, nil
+	case errBefore == nil:
+		return &before, nil
+	case errAfter == nil:
+		return &after, nil
+	case errBefore == gorm.ErrRecordNotFound && errAfter == gorm.ErrRecordNotFound:
+		return nil, nil
+	case errBefore != gorm.ErrRecordNotFound:
+		return nil, errBefore
+	default:
+		return nil, errAfter
+	}
+}
+
+// GetLatestSnapshot returns a cluster's most recent snapshot, or nil
+// when none has been recorded yet.
+func (s *MetricsStore) GetLatestSnapshot(cluster string) (*models.MetricSnapshot, error) {
+	var snap models.MetricSnapshot
+	err := s.db.
+		Where("cluster = ?", cluster).
+		Order("timestamp desc").
+		First(&snap).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &snap, nil
+}
+
+// SaveAlert persists a new alert.
+func (s *MetricsStore) SaveAlert(alert *models.Alert) error {
+	return s.db.Create(alert).Error
+}
+
+// UpdateAlert persists changes to an existing alert.
+func (s *MetricsStore) UpdateAlert(alert *models.Alert) error {
+	return s.db.Save(alert).Error
+}
+
+// GetActiveAlert returns the unresolved alert for a cluster and kind,
+// or nil if none exists.
+func (s *MetricsStore) GetActiveAlert(cluster, kind string) (*models.Alert, error) {
+	var alert models.Alert
+	err := s.db.
+		Where("cluster = ? AND kind = ? AND resolved = ?", cluster, kind, false).
+		First(&alert).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &alert, nil
+}
+
+// GetAlerts returns alerts, optionally filtered by cluster and
+// optionally including resolved ones, newest first.
+func (s *MetricsStore) GetAlerts(cluster string, includeResolved bool, limit int) ([]models.Alert, error) {
+	q := s.db.Order("timestamp desc")
+	if cluster != "" {
+		q = q.Where("cluster = ?", cluster)
+	}
+	if !includeResolved {
+		q = q.Where("resolved = ?", false)
+	}
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	var alerts []models.Alert
+	err := q.Find(&alerts).Error
+	return alerts, err
+}
+
+// AlertCounts aggregates a cluster's unresolved alerts.
+type AlertCounts struct {
+	Active   int
+	Critical int
+}
+
+// CountActiveAlerts returns per-cluster unresolved alert counts in a
+// single grouped query, keyed by cluster name.
+func (s *MetricsStore) CountActiveAlerts() (map[string]AlertCounts, error) {
+	var rows []struct {
+		Cluster  string
+		Active   int
+		Critical int
+	}
+	err := s.db.Model(&models.Alert{}).
+		Select("cluster, count(*) as active, sum(case when severity = ? then 1 else 0 end) as critical", models.SeverityCritical).
+		Where("resolved = ?", false).
+		Group("cluster").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	counts := make(map[string]AlertCounts, len(rows))
+	for _, row := range rows {
+		counts[row.Cluster] = AlertCounts{Active: row.Active, Critical: row.Critical}
+	}
+	return counts, nil
+}
+
+// AlertStat is one time-bucket/severity cell of the alert statistics.
+type AlertStat struct {
+	Bucket   time.Time       `json:"bucket"`
+	Severity models.Severity `json:"severity"`
+	Count    int             `json:"count"`
+}
+
+// GetAlertStats counts alerts first raised since the given time,
+// grouped into fixed-width buckets by severity — the data behind an
+// "alerts per day" trend chart. The grouping happens in SQL on the
+// truncated timestamp, so the alert table never crosses the wire row
+// by row.
+func (s *MetricsStore) GetAlertStats(since time.Time, bucket time.Duration) ([]AlertStat, error) {
+	secs := int64(bucket.Seconds())
+	if secs <= 0 {
+		return nil, fmt.Errorf("bucket must be positive")
+	}
+	var rows []struct {
+		Bucket   int64
+		Severity models.Severity
+		Count    int
+	}
+	err := s.db.Model(&models.Alert{}).
+		Select("(CAST(strftime('%s', first_seen) AS INTEGER) / ?) * ? AS bucket, severity, COUNT(*) AS count", secs, secs).
+		Where("first_seen >= ?", since).
+		Group("bucket, severity").
+		Order("bucket asc").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	stats := make([]AlertStat, 0, len(rows))
+	for _, r := range rows {
+		stats = append(stats, AlertStat{
+			Bucket:   time.Unix(r.Bucket, 0).UTC(),
+			Severity: r.Severity,
+			Count:    r.Count,
+		})
+	}
+	return stats, nil
+}
+
+// RecordTransition persists one cluster status transition.
+func (s *MetricsStore) RecordTransition(t *models.StatusTransition) error {
+	return s.db.Create(t).Error
+}
+
+// GetTransitions returns a cluster's status transitions, oldest first,
+// optionally capped at limit.
+func (s *MetricsStore) GetTransitions(cluster string, limit int) ([]models.StatusTransition, error) {
+	q := s.db.Where("cluster = ?", cluster).Order("timestamp asc")
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	var transitions []models.StatusTransition
+	err := q.Find(&transitions).Error
+	return transitions, err
+}
+
+// UpdateAlertSeverity changes the severity of an existing alert.
+func (s *MetricsStore) UpdateAlertSeverity(id uint, severity models.Severity) error {
+	return s.db.Model(&models.Alert{}).Where("id = ?", id).
+		Update("severity", severity).Error
+}
+
+// ResolveAlert marks a single alert resolved.
+func (s *MetricsStore) ResolveAlert(id uint) error {
+	return s.db.Model(&models.Alert{}).Where("id = ?", id).
+		Update("resolved", true).Error
+}
+
+// SaveClusterThresholds creates or replaces a cluster's threshold
+// override.
+func (s *MetricsStore) SaveClusterThresholds(t *models.ClusterThresholds) error {
+	return s.db.Save(t).Error
+}
+
+// GetClusterThresholds returns a cluster's threshold override, or nil
+// when the cluster uses the defaults.
+func (s *MetricsStore) GetClusterThresholds(cluster string) (*models.ClusterThresholds, error) {
+	var t models.ClusterThresholds
+	err := s.db.First(&t, "cluster = ?", cluster).Error
+	if err == gorm.ErrRecordNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetAllClusterThresholds returns every stored threshold override.
+func (s *MetricsStore) GetAllClusterThresholds() ([]models.ClusterThresholds, error) {
+	var overrides []models.ClusterThresholds
+	err := s.db.Find(&overrides).Error
+	return overrides, err
+}
+
+// ResolveAllForCluster marks all of a cluster's active alerts resolved
+// in one UPDATE, optionally only those of the given severity, and
+// returns how many rows changed.
+func (s *MetricsStore) ResolveAllForCluster(cluster string, severity models.Severity) (int64, error) {
+	q := s.db.Model(&models.Alert{}).
+		Where("cluster = ? AND resolved = ?", cluster, false)
+	if severity != "" {
+		q = q.Where("severity = ?", severity)
+	}
+	res := q.Update("resolved", true)
+	return res.RowsAffected, res.Error
+}
+
+// SaveSilence persists a new silence rule.
+func (s *MetricsStore) SaveSilence(silence *models.Silence) error {
+	return s.db.Create(silence).Error
+}
+
+// DeleteSilence removes a silence rule by id.
+func (s *MetricsStore) DeleteSilence(id uint) error {
+	return s.db.Delete(&models.Silence{}, id).Error
+}
+
+// GetActiveSilences returns the silence rules in effect at the given
+// time; expired rules are excluded but not deleted.
+func (s *MetricsStore) GetActiveSilences(now time.Time) ([]models.Silence, error) {
+	var silences []models.Silence
+	err := s.db.
+		Where("expires_at IS NULL OR expires_at > ?", now).
+		Order("id asc").
+		Find(&silences).Error
+	return silences, err
+}
+
+// SaveAnnotation persists one timeline annotation.
+func (s *MetricsStore) SaveAnnotation(a *models.MetricAnnotation) error {
+	return s.db.Create(a).Error
+}
+
+// GetAnnotations returns a cluster's annotations since the given time,
+// oldest first, to overlay on the history window.
+func (s *MetricsStore) GetAnnotations(cluster string, since time.Time) ([]models.MetricAnnotation, error) {
+	var annotations []models.MetricAnnotation
+	err := s.db.
+		Where("cluster = ? AND timestamp >= ?", cluster, since).
+		Order("timestamp asc").
+		Find(&annotations).Error
+	return annotations, err
+}
+
+// SaveNotificationLog persists one delivery attempt record.
+func (s *MetricsStore) SaveNotificationLog(entry *models.NotificationLog) error {
+	return s.db.Create(entry).Error
+}
+
+// GetNotificationLogs returns delivery attempts, newest first,
+// optionally filtered by cluster and capped at limit.
+func (s *MetricsStore) GetNotificationLogs(cluster string, limit int) ([]models.NotificationLog, error) {
+	q := s.db.Order("timestamp desc")
+	if cluster != "" {
+		q = q.Where("cluster = ?", cluster)
+	}
+	if limit > 0 {
+		q = q.Limit(limit)
+	}
+	var logs []models.NotificationLog
+	err := q.Find(&logs).Error
+	return logs, err
+}
+
+// Cleanup deletes snapshots and resolved alerts older than the cutoff.
+func (s *MetricsStore) Cleanup(olderThan time.Time) error {
+	if err := s.db.Where("timestamp < ?", olderThan).
+		Delete(&models.MetricSnapshot{}).Error; err != nil {
+		return err
+	}
+	if err := s.db.Where("timestamp < ?", olderThan).
+		Delete(&models.NodeSnapshot{}).Error; err != nil {
+		return err
+	}
+	if err := s.db.Where("resolved = ? AND timestamp < ?", true, olderThan).
+		Delete(&models.Alert{}).Error; err != nil {
+		return err
+	}
+	if err := s.db.Where("timestamp < ?", olderThan).
+		Delete(&models.StatusTransition{}).Error; err != nil {
+		return err
+	}
+	if err := s.db.Where("timestamp < ?", olderThan).
+		Delete(&models.NotificationLog{}).Error; err != nil {
+		return err
+	}
+	if err := s.db.Where("timestamp < ?", olderThan).
+		Delete(&models.MetricAnnotation{}).Error; err != nil {
+		return err
+	}
+	return s.db.Where("expires_at IS NOT NULL AND expires_at < ?", olderThan).
+		Delete(&models.Silence{}).Error
+}
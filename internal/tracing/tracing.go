@@ -0,0 +1,49 @@
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.21.0"
+)
+
+// TracerName is the instrumentation scope used for all spans.
+const TracerName = "github.com/rishabh998186/kdash-monitor"
+
+// Setup configures the global tracer provider with an OTLP/HTTP
+// exporter when OTEL_EXPORTER_OTLP_ENDPOINT is set. Without an
+// endpoint, tracing stays a no-op and the returned shutdown does
+// nothing.
+func Setup(ctx context.Context) (func(context.Context) error, error) {
+	endpoint := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT")
+	if endpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+	exporter, err := otlptracehttp.New(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("creating OTLP exporter: %w", err)
+	}
+	res, err := resource.New(ctx,
+		resource.WithAttributes(semconv.ServiceName("kdash-monitor")),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("building trace resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+	log.Printf("tracing enabled, exporting to %s", endpoint)
+	return provider.Shutdown, nil
+}